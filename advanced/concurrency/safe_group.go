@@ -0,0 +1,153 @@
+// A panic in any goroutine crashes the whole program, recover() only works in the goroutine that panicked.
+// SafeGo and Group wrap the goroutines they start with a deferred recover, turning the panic into an error.
+// The APIs contains:
+// SafeGo(func(), PanicSink) - fire-and-forget goroutine, panics go to the sink
+// NewGroup(ctx, ...GroupOption) - errgroup-style group with a derived context
+// Group.Go(func(ctx) error) - start a task
+// Group.Wait() - wait all tasks, return the first error (a panic counts as an error)
+// The first error cancels the group's context, so sibling tasks can stop early.
+
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	recovered "go-practice/basics/panic"
+)
+
+// PanicError is the same type the panic package's ProcessFileWith returns, so one
+// errors.As target catches a recovered panic from either.
+type PanicError = recovered.PanicError
+
+// PanicSink receives recovered panics, e.g. to log them or send them to an error tracker.
+type PanicSink func(err *PanicError)
+
+// PrintPanicSink prints the panic and its stack.
+func PrintPanicSink(err *PanicError) {
+	fmt.Printf("%v\n%s", err, err.Stack)
+}
+
+// recoverInto turns a panic into a *PanicError, reports it to sink and stores it in *errp.
+// It must be called directly by defer.
+func recoverInto(errp *error, sink PanicSink) {
+	if r := recover(); r != nil {
+		pe := &PanicError{Value: r, Stack: debug.Stack()}
+		if sink != nil {
+			sink(pe)
+		}
+		*errp = pe
+	}
+}
+
+// SafeGo runs fn in a new goroutine; a panic is reported to sink instead of crashing the program.
+// A nil sink means PrintPanicSink, so a panic is never silently dropped.
+func SafeGo(fn func(), sink PanicSink) {
+	if sink == nil {
+		sink = PrintPanicSink
+	}
+	go func() {
+		var err error
+		defer recoverInto(&err, sink)
+		fn()
+	}()
+}
+
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	wg     sync.WaitGroup
+	sink   PanicSink
+
+	errOnce sync.Once
+	err     error
+}
+
+type GroupOption func(*Group)
+
+func WithPanicSink(sink PanicSink) GroupOption {
+	return func(g *Group) {
+		g.sink = sink
+	}
+}
+
+// NewGroup returns a group and the context its tasks run with; the context is
+// canceled on the first error or when Wait returns.
+func NewGroup(ctx context.Context, opts ...GroupOption) (*Group, context.Context) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	g := &Group{ctx: ctx, cancel: cancel}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g, ctx
+}
+
+func (g *Group) Go(task func(ctx context.Context) error) {
+	g.wg.Add(1)
+
+	go func() {
+		defer g.wg.Done()
+
+		var err error
+		func() {
+			defer recoverInto(&err, g.sink)
+			err = task(g.ctx)
+		}()
+
+		if err != nil {
+			g.errOnce.Do(func() {
+				g.err = err
+				g.cancel(err)
+			})
+		}
+	}()
+}
+
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel(nil)
+	return g.err
+}
+
+func SafeGroupTest() {
+	// SafeGo: the panic is reported, the program keeps running
+	reported := make(chan struct{})
+	SafeGo(func() {
+		var cache map[string]int
+		cache["key"] = 1 // assignment to entry in nil map
+	}, func(err *PanicError) {
+		fmt.Println("sink received:", err)
+		close(reported)
+	})
+	<-reported
+
+	// Group: one task panics, its siblings see the canceled context and stop
+	g, ctx := NewGroup(context.Background())
+	for i := range 3 {
+		g.Go(func(ctx context.Context) error {
+			for step := 0; ; step++ {
+				select {
+				case <-ctx.Done():
+					fmt.Printf("task %d stopped: %v\n", i, context.Cause(ctx))
+					return ctx.Err()
+				case <-time.After(50 * time.Millisecond):
+					if i == 1 && step == 2 {
+						var items []int
+						_ = items[step] // index out of range
+					}
+				}
+			}
+		})
+	}
+
+	err := g.Wait()
+	var pe *PanicError
+	if errors.As(err, &pe) {
+		fmt.Printf("group failed: %v (stack: %d bytes)\n", pe, len(pe.Stack))
+	}
+	fmt.Println("group context after Wait:", ctx.Err())
+}
@@ -0,0 +1,92 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+)
+
+func TestSafeGoReportsPanic(t *testing.T) {
+	reported := make(chan *PanicError, 1)
+	SafeGo(func() {
+		var m map[string]int
+		m["key"] = 1
+	}, func(err *PanicError) { reported <- err })
+
+	err := <-reported
+	var rerr runtime.Error
+	if !errors.As(err, &rerr) || len(err.Stack) == 0 {
+		t.Errorf("sink got %v with %d bytes of stack, want a runtime.Error and a stack", err, len(err.Stack))
+	}
+}
+
+func TestGroupConvertsPanicToError(t *testing.T) {
+	var sunk []*PanicError
+	g, _ := NewGroup(context.Background(), WithPanicSink(func(err *PanicError) { sunk = append(sunk, err) }))
+	g.Go(func(context.Context) error {
+		panic("task bug")
+	})
+
+	err := g.Wait()
+	var pe *PanicError
+	if !errors.As(err, &pe) || pe.Value != "task bug" {
+		t.Fatalf("Wait = %v, want a *PanicError for the panic", err)
+	}
+	if len(sunk) != 1 || sunk[0] != pe {
+		t.Errorf("sink got %v, want the same *PanicError", sunk)
+	}
+}
+
+func TestGroupWaitReturnsTheFirstError(t *testing.T) {
+	first := errors.New("first")
+	g, ctx := NewGroup(context.Background())
+	failed := make(chan struct{})
+
+	g.Go(func(context.Context) error {
+		defer close(failed)
+		return first
+	})
+	for range 3 {
+		// these fail only after the group was canceled by the first error
+		g.Go(func(ctx context.Context) error {
+			<-failed
+			<-ctx.Done()
+			return ctx.Err()
+		})
+	}
+	g.Go(func(ctx context.Context) error {
+		<-ctx.Done()
+		panic("after the first error") // a later panic doesn't replace it either
+	})
+
+	if err := g.Wait(); err != first {
+		t.Errorf("Wait = %v, want the first error", err)
+	}
+	if cause := context.Cause(ctx); cause != first {
+		t.Errorf("context cause = %v, want the first error", cause)
+	}
+}
+
+func TestGroupSucceeds(t *testing.T) {
+	g, ctx := NewGroup(context.Background())
+	results := make([]int, 5)
+	for i := range results {
+		g.Go(func(ctx context.Context) error {
+			results[i] = i * i
+			return ctx.Err()
+		})
+	}
+	if err := g.Wait(); err != nil {
+		t.Fatalf("Wait = %v, want nil", err)
+	}
+	if results[4] != 16 {
+		t.Errorf("results = %v", results)
+	}
+	if ctx.Err() == nil {
+		t.Error("the group's context is still live after Wait")
+	}
+	if cause := context.Cause(ctx); cause != context.Canceled {
+		t.Errorf("cause after a clean Wait = %v, want context.Canceled", cause)
+	}
+}
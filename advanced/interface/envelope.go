@@ -0,0 +1,120 @@
+package interface_example
+
+// ParseJSON decodes the whole response into map[string]interface{}, marshals "data" back
+// to bytes and decodes it a second time, and it only knows about User. With generics the
+// envelope can say what it carries instead:
+//
+// user, err := DecodeEnvelope[User](body)
+// users, err := DecodeEnvelope[[]User](body)
+// env, err := ParseEnvelope[User](body) // Envelope[User], when the message matters too
+//
+// "data" is held as json.RawMessage (the raw bytes, not decoded), so it's decoded exactly
+// once, straight into T, and only when the response wasn't an error.
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var ErrMissingData = errors.New("data field not found")
+
+// APIError is a response with a non-zero code. Details holds its "data", if any.
+type APIError struct {
+	Code    int
+	Message string
+	Details json.RawMessage
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("api error %d: %s", e.Code, e.Message)
+}
+
+// Is matches on the code, so callers can write errors.Is(err, &APIError{Code: 404}).
+func (e *APIError) Is(target error) bool {
+	t, ok := target.(*APIError)
+	return ok && t.Code == e.Code
+}
+
+// Envelope is the {code, message, data} response shape; code 0 means success.
+type Envelope[T any] struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    T      `json:"data"`
+}
+
+// rawEnvelope is an Envelope whose data hasn't been decoded yet.
+type rawEnvelope struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// ParseEnvelope decodes a successful response into an Envelope[T], or returns an *APIError.
+func ParseEnvelope[T any](body []byte) (Envelope[T], error) {
+	var raw rawEnvelope
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return Envelope[T]{}, err
+	}
+	return unwrapEnvelope[T](raw)
+}
+
+// DecodeEnvelope returns the payload of a successful response, or an *APIError.
+func DecodeEnvelope[T any](body []byte) (T, error) {
+	env, err := ParseEnvelope[T](body)
+	return env.Data, err
+}
+
+// DecodeEnvelopeFrom is DecodeEnvelope for a stream, e.g. an http.Response body.
+func DecodeEnvelopeFrom[T any](r io.Reader) (T, error) {
+	var raw rawEnvelope
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		var zero T
+		return zero, err
+	}
+	env, err := unwrapEnvelope[T](raw)
+	return env.Data, err
+}
+
+func unwrapEnvelope[T any](raw rawEnvelope) (Envelope[T], error) {
+	env := Envelope[T]{Code: raw.Code, Message: raw.Message}
+	if raw.Code != 0 {
+		return env, &APIError{Code: raw.Code, Message: raw.Message, Details: raw.Data}
+	}
+	if raw.Data == nil || bytes.Equal(raw.Data, []byte("null")) {
+		return env, ErrMissingData // "data": null carries no payload either
+	}
+	if err := json.Unmarshal(raw.Data, &env.Data); err != nil {
+		return env, fmt.Errorf("decoding data as %T: %w", env.Data, err)
+	}
+	return env, nil
+}
+
+func EnvelopeTest() {
+	user, err := DecodeEnvelope[User]([]byte(`{"code":0,"message":"ok","data":{"id":101,"name":"Alice","age":23}}`))
+	fmt.Printf("user: %+v err=%v\n", user, err)
+	env, err := ParseEnvelope[User]([]byte(`{"code":0,"message":"created","data":{"id":102,"name":"Dan"}}`))
+	fmt.Printf("envelope: %+v err=%v\n", env, err)
+
+	// Any payload type works, including slices and maps
+	users, err := DecodeEnvelope[[]User]([]byte(`{"code":0,"message":"ok","data":[{"id":1,"name":"Bob"},{"id":2,"name":"Carol"}]}`))
+	fmt.Printf("users: %+v err=%v\n", users, err)
+	counts, err := DecodeEnvelope[map[string]int]([]byte(`{"code":0,"message":"ok","data":{"active":12,"banned":1}}`))
+	fmt.Printf("counts: %v err=%v\n", counts, err)
+
+	// A non-zero code becomes an *APIError, whatever the payload type
+	_, err = DecodeEnvelope[User]([]byte(`{"code":404,"message":"user not found","data":{"id":7}}`))
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		fmt.Printf("error: %v, details=%s, is 404: %v\n", apiErr, apiErr.Details, errors.Is(err, &APIError{Code: 404}))
+	}
+
+	_, err = DecodeEnvelope[User]([]byte(`{"code":0,"message":"ok"}`))
+	fmt.Println("missing data:", err)
+	_, err = DecodeEnvelope[User]([]byte(`{"code":0,"message":"ok","data":null}`))
+	fmt.Println("null data:", err)
+	_, err = DecodeEnvelope[User]([]byte(`{"code":0,"message":"ok","data":{"id":"one"}}`))
+	fmt.Println("wrong type:", err)
+}
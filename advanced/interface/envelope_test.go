@@ -0,0 +1,59 @@
+package interface_example
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+var envelopeBody = []byte(`{"code":0,"message":"ok","data":{"id":101,"name":"Alice","age":23}}`)
+
+// BenchmarkParseJSON decodes, re-encodes and decodes "data" again.
+func BenchmarkParseJSON(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseJSON(envelopeBody); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkDecodeEnvelope decodes "data" once, straight into User.
+func BenchmarkDecodeEnvelope(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := DecodeEnvelope[User](envelopeBody); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestDecodeEnvelopeMissingData(t *testing.T) {
+	for _, body := range []string{
+		`{"code":0,"message":"ok"}`,
+		`{"code":0,"message":"ok","data":null}`,
+	} {
+		if _, err := DecodeEnvelope[User]([]byte(body)); !errors.Is(err, ErrMissingData) {
+			t.Errorf("DecodeEnvelope(%s) = %v, want ErrMissingData", body, err)
+		}
+		if _, err := DecodeEnvelopeFrom[User](strings.NewReader(body)); !errors.Is(err, ErrMissingData) {
+			t.Errorf("DecodeEnvelopeFrom(%s) = %v, want ErrMissingData", body, err)
+		}
+	}
+}
+
+func TestParseEnvelope(t *testing.T) {
+	env, err := ParseEnvelope[User](envelopeBody)
+	if err != nil || env.Code != 0 || env.Message != "ok" || env.Data.Name != "Alice" {
+		t.Errorf("ParseEnvelope = %+v, %v", env, err)
+	}
+
+	env, err = ParseEnvelope[User]([]byte(`{"code":404,"message":"user not found","data":{"id":7}}`))
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != 404 || string(apiErr.Details) != `{"id":7}` {
+		t.Errorf("ParseEnvelope of an error = %v, want an *APIError with code 404", err)
+	}
+	if env.Code != 404 || env.Message != "user not found" {
+		t.Errorf("ParseEnvelope of an error = %+v, want code and message kept", env)
+	}
+}
@@ -0,0 +1,475 @@
+package interface_example
+
+// An in-process publish/subscribe bus. Publishers and subscribers only share a topic name,
+// a dot-separated string like "user.created"; subscribers may use wildcards:
+//
+// user.*    one segment: user.created, user.deleted
+// order.>   one or more trailing segments: order.paid, order.item.added
+//
+// Every subscriber has its own buffered queue and goroutine, so a slow subscriber doesn't
+// hold up the others. When its queue is full the Backpressure policy decides: Block makes
+// the publisher wait, DropOldest and DropNewest keep the publisher moving and lose an event.
+//
+// Delivery is at-least-once: the handler acknowledges an event by returning nil. An error
+// (or a panic) is a negative acknowledgement and the event is delivered again, up to
+// MaxAttempts, before it's given up on. Events of one subscriber are handled one at a time,
+// in order, so a retried event holds back the ones behind it.
+//
+// Close stops new publishes and waits for every queued event to be handled.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	ErrBusClosed      = errors.New("event bus is closed")
+	ErrInvalidPattern = errors.New("invalid topic pattern")
+)
+
+type Backpressure int
+
+const (
+	Block Backpressure = iota
+	DropOldest
+	DropNewest
+)
+
+type Event struct {
+	ID      uint64
+	Topic   string
+	Payload any
+	Time    time.Time
+	Attempt int // 1 on first delivery, higher on redelivery
+}
+
+// Handler acknowledges an event by returning nil; an error asks for redelivery.
+type Handler func(e Event) error
+
+type SubscriptionStats struct {
+	Received    int // queued for this subscriber
+	Acked       int
+	Redelivered int
+	Dropped     int // lost to backpressure
+	Failed      int // gave up after MaxAttempts
+	Pending     int // queued or being handled right now
+}
+
+type Bus struct {
+	mu     sync.RWMutex
+	subs   map[*Subscription]struct{}
+	closed bool
+	nextID atomic.Uint64
+	abort  chan struct{} // closed when Close gives up waiting
+}
+
+func NewBus() *Bus {
+	return &Bus{subs: make(map[*Subscription]struct{}), abort: make(chan struct{})}
+}
+
+// DefaultBus is the bus behind the package-level Publish.
+var DefaultBus = NewBus()
+
+type Subscription struct {
+	bus     *Bus
+	pattern []string
+	handler Handler
+	accept  func(payload any) bool
+
+	capacity    int
+	policy      Backpressure
+	maxAttempts int
+	retryDelay  time.Duration
+	onFailure   func(e Event, err error)
+
+	mu       sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	queue    []Event
+	inflight bool
+	closed   bool
+	stats    SubscriptionStats
+	done     chan struct{}
+}
+
+type SubscribeOption func(*Subscription)
+
+// WithBuffer sets the queue size; the default is 64.
+func WithBuffer(n int) SubscribeOption {
+	return func(s *Subscription) {
+		s.capacity = max(n, 1)
+	}
+}
+
+func WithBackpressure(policy Backpressure) SubscribeOption {
+	return func(s *Subscription) {
+		s.policy = policy
+	}
+}
+
+// WithMaxAttempts bounds deliveries per event; 0 retries forever. The default is 3.
+func WithMaxAttempts(n int) SubscribeOption {
+	return func(s *Subscription) {
+		s.maxAttempts = n
+	}
+}
+
+func WithRetryDelay(d time.Duration) SubscribeOption {
+	return func(s *Subscription) {
+		s.retryDelay = d
+	}
+}
+
+// WithFailureHandler is called with events that used up their attempts.
+func WithFailureHandler(fn func(e Event, err error)) SubscribeOption {
+	return func(s *Subscription) {
+		s.onFailure = fn
+	}
+}
+
+func (b *Bus) Subscribe(pattern string, handler Handler, opts ...SubscribeOption) (*Subscription, error) {
+	return b.subscribe(pattern, handler, nil, opts)
+}
+
+// SubscribeTyped only receives events whose payload is a T; others are not queued at all.
+func SubscribeTyped[T any](b *Bus, pattern string, handler func(e Event, payload T) error, opts ...SubscribeOption) (*Subscription, error) {
+	accept := func(payload any) bool {
+		_, ok := payload.(T)
+		return ok
+	}
+	return b.subscribe(pattern, func(e Event) error {
+		return handler(e, e.Payload.(T))
+	}, accept, opts)
+}
+
+func (b *Bus) subscribe(pattern string, handler Handler, accept func(any) bool, opts []SubscribeOption) (*Subscription, error) {
+	segments, err := parsePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Subscription{
+		bus:         b,
+		pattern:     segments,
+		handler:     handler,
+		accept:      accept,
+		capacity:    64,
+		maxAttempts: 3,
+		retryDelay:  10 * time.Millisecond,
+		done:        make(chan struct{}),
+	}
+	s.onFailure = func(e Event, err error) {
+		fmt.Printf("[%s] event %d failed after %d attempts: %v\n", e.Topic, e.ID, e.Attempt, err)
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.notEmpty = sync.NewCond(&s.mu)
+	s.notFull = sync.NewCond(&s.mu)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, ErrBusClosed
+	}
+	b.subs[s] = struct{}{}
+	go s.run()
+	return s, nil
+}
+
+// Publish queues the event for every matching subscriber. With the Block policy it waits
+// for queue space until ctx is done.
+func (b *Bus) Publish(ctx context.Context, topic string, payload any) error {
+	if _, err := parsePattern(topic); err != nil || strings.ContainsAny(topic, "*>") {
+		return fmt.Errorf("%w: topic %q", ErrInvalidPattern, topic)
+	}
+
+	b.mu.RLock()
+	if b.closed {
+		b.mu.RUnlock()
+		return ErrBusClosed
+	}
+	var targets []*Subscription
+	for s := range b.subs {
+		if matchTopic(s.pattern, topic) && (s.accept == nil || s.accept(payload)) {
+			targets = append(targets, s)
+		}
+	}
+	b.mu.RUnlock()
+
+	e := Event{ID: b.nextID.Add(1), Topic: topic, Payload: payload, Time: time.Now()}
+	var errs []error
+	for _, s := range targets {
+		if err := s.enqueue(ctx, e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close stops accepting events and waits until every subscriber has handled its queue.
+// If ctx ends first, pending retries are abandoned and ctx's error is returned.
+func (b *Bus) Close(ctx context.Context) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrBusClosed
+	}
+	b.closed = true
+	subs := make([]*Subscription, 0, len(b.subs))
+	for s := range b.subs {
+		subs = append(subs, s)
+	}
+	b.mu.Unlock()
+
+	for _, s := range subs {
+		s.close()
+	}
+	for _, s := range subs {
+		select {
+		case <-s.done:
+		case <-ctx.Done():
+			close(b.abort)
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Unsubscribe stops new events and waits for the queued ones to be handled.
+func (s *Subscription) Unsubscribe() {
+	s.bus.mu.Lock()
+	delete(s.bus.subs, s)
+	s.bus.mu.Unlock()
+
+	s.close()
+	<-s.done
+}
+
+func (s *Subscription) Stats() SubscriptionStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := s.stats
+	stats.Pending = len(s.queue)
+	if s.inflight {
+		stats.Pending++
+	}
+	return stats
+}
+
+func (s *Subscription) close() {
+	s.mu.Lock()
+	s.closed = true
+	s.notEmpty.Broadcast()
+	s.notFull.Broadcast()
+	s.mu.Unlock()
+}
+
+func (s *Subscription) enqueue(ctx context.Context, e Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrBusClosed
+	}
+	if len(s.queue) >= s.capacity {
+		switch s.policy {
+		case DropNewest:
+			s.stats.Dropped++
+			return nil
+		case DropOldest:
+			s.queue = s.queue[1:]
+			s.stats.Dropped++
+		default:
+			// wake the waiting publisher if its context ends
+			stop := context.AfterFunc(ctx, func() {
+				s.mu.Lock()
+				s.notFull.Broadcast()
+				s.mu.Unlock()
+			})
+			defer stop()
+			for len(s.queue) >= s.capacity && !s.closed && ctx.Err() == nil {
+				s.notFull.Wait()
+			}
+			if s.closed {
+				return ErrBusClosed
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+	}
+
+	s.queue = append(s.queue, e)
+	s.stats.Received++
+	s.notEmpty.Signal()
+	return nil
+}
+
+func (s *Subscription) run() {
+	defer close(s.done)
+	for {
+		s.mu.Lock()
+		for len(s.queue) == 0 && !s.closed {
+			s.notEmpty.Wait()
+		}
+		if len(s.queue) == 0 {
+			s.mu.Unlock()
+			return // closed and drained
+		}
+		e := s.queue[0]
+		s.queue = s.queue[1:]
+		s.inflight = true
+		s.notFull.Signal()
+		s.mu.Unlock()
+
+		s.deliver(e)
+
+		s.mu.Lock()
+		s.inflight = false
+		s.mu.Unlock()
+	}
+}
+
+// deliver calls the handler until it acknowledges e or the attempts run out.
+func (s *Subscription) deliver(e Event) {
+	for attempt := 1; ; attempt++ {
+		e.Attempt = attempt
+		err := s.call(e)
+		if err == nil {
+			s.count(func(st *SubscriptionStats) { st.Acked++ })
+			return
+		}
+
+		aborted := false
+		if s.maxAttempts == 0 || attempt < s.maxAttempts {
+			select {
+			case <-time.After(s.retryDelay):
+				s.count(func(st *SubscriptionStats) { st.Redelivered++ })
+				continue
+			case <-s.bus.abort:
+				aborted = true
+			}
+		}
+		if aborted {
+			err = fmt.Errorf("%w: shutdown deadline passed: %v", ErrBusClosed, err)
+		}
+		s.count(func(st *SubscriptionStats) { st.Failed++ })
+		s.onFailure(e, err)
+		return
+	}
+}
+
+// call runs the handler; a panic counts as a failed delivery.
+func (s *Subscription) call(e Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panicked: %v", r)
+		}
+	}()
+	return s.handler(e)
+}
+
+func (s *Subscription) count(update func(*SubscriptionStats)) {
+	s.mu.Lock()
+	update(&s.stats)
+	s.mu.Unlock()
+}
+
+func parsePattern(pattern string) ([]string, error) {
+	segments := strings.Split(pattern, ".")
+	for i, seg := range segments {
+		switch {
+		case seg == "":
+			return nil, fmt.Errorf("%w: empty segment in %q", ErrInvalidPattern, pattern)
+		case seg == ">" && i != len(segments)-1:
+			return nil, fmt.Errorf("%w: '>' must be the last segment in %q", ErrInvalidPattern, pattern)
+		}
+	}
+	return segments, nil
+}
+
+func matchTopic(pattern []string, topic string) bool {
+	segments := strings.Split(topic, ".")
+	for i, p := range pattern {
+		if p == ">" {
+			return len(segments) > i
+		}
+		if i >= len(segments) || (p != "*" && p != segments[i]) {
+			return false
+		}
+	}
+	return len(segments) == len(pattern)
+}
+
+type OrderPlaced struct {
+	ID    int
+	Total float64
+}
+
+func EventBusTest() {
+	bus := NewBus()
+
+	// Typed subscriber on a wildcard: only User payloads on user.<anything>
+	users, _ := SubscribeTyped(bus, "user.*", func(e Event, u User) error {
+		fmt.Printf("users: %s %+v\n", e.Topic, u)
+		return nil
+	})
+
+	// Flaky subscriber: fails the first delivery of each event, then acknowledges it
+	var audited atomic.Int32
+	audit, _ := bus.Subscribe(">", func(e Event) error {
+		if e.Attempt == 1 {
+			return errors.New("audit store unavailable")
+		}
+		audited.Add(1)
+		return nil
+	}, WithRetryDelay(5*time.Millisecond))
+
+	// Slow subscribers with tiny buffers show the backpressure policies
+	slow := func(Event) error {
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	}
+	dropOldest, _ := bus.Subscribe("order.>", slow, WithBuffer(2), WithBackpressure(DropOldest))
+	dropNewest, _ := bus.Subscribe("order.>", slow, WithBuffer(2), WithBackpressure(DropNewest))
+	blocking, _ := bus.Subscribe("order.>", slow, WithBuffer(2), WithBackpressure(Block))
+
+	// A handler that always fails ends up in the failure handler
+	_, _ = SubscribeTyped(bus, "order.paid", func(e Event, o OrderPlaced) error {
+		return fmt.Errorf("payment service rejected order %d", o.ID)
+	}, WithMaxAttempts(2), WithRetryDelay(time.Millisecond))
+
+	ctx := context.Background()
+	bus.Publish(ctx, "user.created", User{ID: 1, Name: "Alice", Age: 30})
+	bus.Publish(ctx, "user.deleted", "not a User, skipped by the typed subscriber")
+
+	start := time.Now()
+	for i := range 6 {
+		bus.Publish(ctx, "order.item.added", OrderPlaced{ID: i, Total: float64(i) * 10})
+	}
+	bus.Publish(ctx, "order.paid", OrderPlaced{ID: 42, Total: 99.5})
+	fmt.Printf("publishing 7 orders took at least %v: %v (Block waits for the slow subscriber)\n",
+		60*time.Millisecond, time.Since(start) >= 60*time.Millisecond)
+
+	fmt.Println("invalid topic:", bus.Publish(ctx, "order..paid", nil))
+
+	// Close waits for every queue to drain
+	shutdown, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	fmt.Println("close:", bus.Close(shutdown))
+	fmt.Println("publish after close:", bus.Publish(ctx, "user.created", User{}))
+
+	for _, sub := range []struct {
+		name string
+		s    *Subscription
+	}{{"users", users}, {"audit", audit}, {"drop-oldest", dropOldest}, {"drop-newest", dropNewest}, {"block", blocking}} {
+		fmt.Printf("%-12s %+v\n", sub.name, sub.s.Stats())
+	}
+	fmt.Println("audited events:", audited.Load())
+}
@@ -0,0 +1,280 @@
+package interface_example
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMatchTopic(t *testing.T) {
+	tests := []struct {
+		pattern, topic string
+		want           bool
+	}{
+		{"user.created", "user.created", true},
+		{"user.created", "user.deleted", false},
+		{"user.*", "user.created", true},
+		{"user.*", "user", false},
+		{"user.*", "user.created.now", false},
+		{"*.created", "order.created", true},
+		{"order.>", "order.paid", true},
+		{"order.>", "order.item.added", true},
+		{"order.>", "order", false},
+		{">", "anything.at.all", true},
+	}
+	for _, tt := range tests {
+		p, err := parsePattern(tt.pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := matchTopic(p, tt.topic); got != tt.want {
+			t.Errorf("matchTopic(%s, %s) = %v, want %v", tt.pattern, tt.topic, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "user.", "a..b", "order.>.paid"} {
+		if _, err := parsePattern(bad); !errors.Is(err, ErrInvalidPattern) {
+			t.Errorf("parsePattern(%q) = %v, want ErrInvalidPattern", bad, err)
+		}
+	}
+}
+
+// recorder is a handler that keeps the payloads it acknowledged.
+type recorder struct {
+	mu   sync.Mutex
+	got  []any
+	gate chan struct{} // if set, every call waits for it
+	busy chan struct{} // if set, signaled when a call starts
+}
+
+func (r *recorder) handle(e Event) error {
+	if r.busy != nil {
+		r.busy <- struct{}{}
+	}
+	if r.gate != nil {
+		<-r.gate
+	}
+	r.mu.Lock()
+	r.got = append(r.got, e.Payload)
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *recorder) payloads() []any {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.got)
+}
+
+func closeBus(t *testing.T, bus *Bus) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := bus.Close(ctx); err != nil {
+		t.Fatalf("Close = %v", err)
+	}
+}
+
+func TestPublishRoutesByTopicAndType(t *testing.T) {
+	bus := NewBus()
+	var all, users recorder
+	bus.Subscribe("user.>", all.handle)
+	SubscribeTyped(bus, "user.*", func(e Event, u User) error { return users.handle(e) })
+
+	ctx := context.Background()
+	bus.Publish(ctx, "user.created", User{ID: 1})
+	bus.Publish(ctx, "user.deleted", "not a user")
+	bus.Publish(ctx, "order.paid", User{ID: 2})
+	if err := bus.Publish(ctx, "user.*", nil); !errors.Is(err, ErrInvalidPattern) {
+		t.Errorf("Publish to a wildcard = %v, want ErrInvalidPattern", err)
+	}
+	closeBus(t, bus)
+
+	if got := all.payloads(); !slices.Equal(got, []any{User{ID: 1}, "not a user"}) {
+		t.Errorf("user.> got %v", got)
+	}
+	if got := users.payloads(); !slices.Equal(got, []any{User{ID: 1}}) {
+		t.Errorf("typed subscriber got %v, want only the User", got)
+	}
+}
+
+// fillQueue publishes 1, waits until the handler is busy with it, then publishes 2..n.
+func fillQueue(t *testing.T, bus *Bus, r *recorder, n int) {
+	t.Helper()
+	ctx := context.Background()
+	bus.Publish(ctx, "tick", 1)
+	<-r.busy
+	for i := 2; i <= n; i++ {
+		if err := bus.Publish(ctx, "tick", i); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBackpressure(t *testing.T) {
+	t.Run("DropNewest", func(t *testing.T) {
+		bus := NewBus()
+		r := &recorder{gate: make(chan struct{}), busy: make(chan struct{}, 10)}
+		sub, _ := bus.Subscribe("tick", r.handle, WithBuffer(2), WithBackpressure(DropNewest))
+		fillQueue(t, bus, r, 5) // 1 in the handler, 2 and 3 queued, 4 and 5 dropped
+		close(r.gate)
+		closeBus(t, bus)
+
+		if got := r.payloads(); !slices.Equal(got, []any{1, 2, 3}) {
+			t.Errorf("handled %v, want [1 2 3]", got)
+		}
+		if st := sub.Stats(); st.Dropped != 2 || st.Received != 3 || st.Acked != 3 {
+			t.Errorf("stats = %+v", st)
+		}
+	})
+
+	t.Run("DropOldest", func(t *testing.T) {
+		bus := NewBus()
+		r := &recorder{gate: make(chan struct{}), busy: make(chan struct{}, 10)}
+		sub, _ := bus.Subscribe("tick", r.handle, WithBuffer(2), WithBackpressure(DropOldest))
+		fillQueue(t, bus, r, 5) // 2 and 3 make way for 4 and 5
+		close(r.gate)
+		closeBus(t, bus)
+
+		if got := r.payloads(); !slices.Equal(got, []any{1, 4, 5}) {
+			t.Errorf("handled %v, want [1 4 5]", got)
+		}
+		if st := sub.Stats(); st.Dropped != 2 {
+			t.Errorf("stats = %+v, want 2 dropped", st)
+		}
+	})
+
+	t.Run("Block", func(t *testing.T) {
+		bus := NewBus()
+		r := &recorder{gate: make(chan struct{}), busy: make(chan struct{}, 10)}
+		bus.Subscribe("tick", r.handle, WithBuffer(2), WithBackpressure(Block))
+		fillQueue(t, bus, r, 3)
+
+		// the queue is full: the publisher waits until its context gives up
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		if err := bus.Publish(ctx, "tick", 4); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("Publish to a full queue = %v, want DeadlineExceeded", err)
+		}
+
+		// or until the handler makes room
+		published := make(chan error)
+		go func() { published <- bus.Publish(context.Background(), "tick", 5) }()
+		select {
+		case err := <-published:
+			t.Fatalf("Publish returned %v with the queue still full", err)
+		case <-time.After(20 * time.Millisecond):
+		}
+		close(r.gate)
+		if err := <-published; err != nil {
+			t.Fatal(err)
+		}
+		closeBus(t, bus)
+
+		if got := r.payloads(); !slices.Equal(got, []any{1, 2, 3, 5}) {
+			t.Errorf("handled %v, want [1 2 3 5]", got)
+		}
+	})
+}
+
+func TestRedelivery(t *testing.T) {
+	bus := NewBus()
+	var mu sync.Mutex
+	var attempts []int
+	var failed []Event
+	sub, _ := bus.Subscribe("job", func(e Event) error {
+		mu.Lock()
+		attempts = append(attempts, e.Attempt)
+		mu.Unlock()
+		switch {
+		case e.Payload == "panics":
+			panic("handler bug")
+		case e.Payload == "fails" || e.Attempt < 2:
+			return errors.New("not yet")
+		}
+		return nil
+	}, WithMaxAttempts(3), WithRetryDelay(time.Millisecond), WithFailureHandler(func(e Event, err error) {
+		failed = append(failed, e)
+	}))
+
+	ctx := context.Background()
+	bus.Publish(ctx, "job", "retried") // fails once, then acknowledged
+	bus.Publish(ctx, "job", "fails")   // fails all three attempts
+	bus.Publish(ctx, "job", "panics")  // a panic is a failure too
+	closeBus(t, bus)
+
+	// one subscriber handles events in order, so retries hold back the next event
+	if want := []int{1, 2, 1, 2, 3, 1, 2, 3}; !slices.Equal(attempts, want) {
+		t.Errorf("attempts = %v, want %v", attempts, want)
+	}
+	if len(failed) != 2 || failed[0].Payload != "fails" || failed[0].Attempt != 3 || failed[1].Payload != "panics" {
+		t.Errorf("failure handler got %+v", failed)
+	}
+	if st := sub.Stats(); st.Acked != 1 || st.Redelivered != 5 || st.Failed != 2 || st.Pending != 0 {
+		t.Errorf("stats = %+v", st)
+	}
+}
+
+func TestCloseDrainsQueues(t *testing.T) {
+	bus := NewBus()
+	r := &recorder{}
+	slow := func(e Event) error {
+		time.Sleep(time.Millisecond)
+		return r.handle(e)
+	}
+	sub, _ := bus.Subscribe("tick", slow, WithBuffer(100))
+	for i := range 20 {
+		bus.Publish(context.Background(), "tick", i)
+	}
+	closeBus(t, bus)
+
+	if got := r.payloads(); len(got) != 20 || got[19] != 19 {
+		t.Errorf("handled %v before Close returned, want all 20 in order", got)
+	}
+	if st := sub.Stats(); st.Pending != 0 {
+		t.Errorf("pending after Close = %d", st.Pending)
+	}
+	if err := bus.Publish(context.Background(), "tick", 20); !errors.Is(err, ErrBusClosed) {
+		t.Errorf("Publish after Close = %v, want ErrBusClosed", err)
+	}
+	if _, err := bus.Subscribe("tick", r.handle); !errors.Is(err, ErrBusClosed) {
+		t.Errorf("Subscribe after Close = %v, want ErrBusClosed", err)
+	}
+	if err := bus.Close(context.Background()); !errors.Is(err, ErrBusClosed) {
+		t.Errorf("second Close = %v, want ErrBusClosed", err)
+	}
+}
+
+func TestCloseDeadlineAbandonsRetries(t *testing.T) {
+	bus := NewBus()
+	failed := make(chan error, 1)
+	bus.Subscribe("tick", func(Event) error { return errors.New("down") },
+		WithMaxAttempts(0), WithRetryDelay(time.Hour), WithFailureHandler(func(e Event, err error) { failed <- err }))
+	bus.Publish(context.Background(), "tick", 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := bus.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Close = %v, want DeadlineExceeded", err)
+	}
+	if err := <-failed; !errors.Is(err, ErrBusClosed) {
+		t.Errorf("abandoned event failed with %v, want ErrBusClosed", err)
+	}
+}
+
+func TestUnsubscribe(t *testing.T) {
+	bus := NewBus()
+	r := &recorder{}
+	sub, _ := bus.Subscribe("tick", r.handle)
+	bus.Publish(context.Background(), "tick", 1)
+	sub.Unsubscribe() // waits for 1 to be handled
+	bus.Publish(context.Background(), "tick", 2)
+	closeBus(t, bus)
+
+	if got := r.payloads(); !slices.Equal(got, []any{1}) {
+		t.Errorf("handled %v, want [1]", got)
+	}
+}
@@ -0,0 +1,279 @@
+package interface_example
+
+// Shape only asks for Area and Perimeter. Extra capabilities are optional interfaces:
+// a shape that has the methods gets the feature, and callers discover it with a type assertion,
+// just like io.Reader and io.WriterTo in the standard library:
+//
+// if b, ok := s.(Bounded); ok { box := b.BoundingBox() }
+//
+// Every shape in this package implements all of them.
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+func (p Point) Add(dx, dy float64) Point {
+	return Point{p.X + dx, p.Y + dy}
+}
+
+// rotateAbout rotates p counter-clockwise by radians around c.
+func (p Point) rotateAbout(c Point, radians float64) Point {
+	sin, cos := math.Sincos(radians)
+	dx, dy := p.X-c.X, p.Y-c.Y
+	return Point{c.X + dx*cos - dy*sin, c.Y + dx*sin + dy*cos}
+}
+
+// scaleAbout moves p away from (or towards) c by factor.
+func (p Point) scaleAbout(c Point, factor float64) Point {
+	return Point{c.X + (p.X-c.X)*factor, c.Y + (p.Y-c.Y)*factor}
+}
+
+func (p Point) distance(q Point) float64 {
+	return math.Hypot(p.X-q.X, p.Y-q.Y)
+}
+
+// BBox is an axis-aligned bounding box.
+type BBox struct {
+	Min, Max Point
+}
+
+func (b BBox) Width() float64 {
+	return b.Max.X - b.Min.X
+}
+
+func (b BBox) Height() float64 {
+	return b.Max.Y - b.Min.Y
+}
+
+func (b BBox) Intersects(o BBox) bool {
+	return b.Min.X <= o.Max.X && o.Min.X <= b.Max.X && b.Min.Y <= o.Max.Y && o.Min.Y <= b.Max.Y
+}
+
+func (b BBox) ContainsPoint(p Point) bool {
+	return p.X >= b.Min.X && p.X <= b.Max.X && p.Y >= b.Min.Y && p.Y <= b.Max.Y
+}
+
+// Union returns the smallest box covering both.
+func (b BBox) Union(o BBox) BBox {
+	return BBox{
+		Min: Point{math.Min(b.Min.X, o.Min.X), math.Min(b.Min.Y, o.Min.Y)},
+		Max: Point{math.Max(b.Max.X, o.Max.X), math.Max(b.Max.Y, o.Max.Y)},
+	}
+}
+
+// bboxOf returns the empty BBox{} when there are no points, e.g. for a zero-value Polygon.
+func bboxOf(points []Point) BBox {
+	if len(points) == 0 {
+		return BBox{}
+	}
+	b := BBox{Min: points[0], Max: points[0]}
+	for _, p := range points[1:] {
+		b = b.Union(BBox{Min: p, Max: p})
+	}
+	return b
+}
+
+type Bounded interface {
+	BoundingBox() BBox
+}
+
+// Container tells whether a point is inside the shape (the boundary counts as inside).
+type Container interface {
+	Contains(p Point) bool
+}
+
+// Transformer returns a transformed copy; Scale and Rotate work around the shape's center.
+// The result may be a different type, e.g. a rotated Rectangle is a Polygon.
+// Scale rejects a factor that isn't positive, which would collapse or mirror the shape.
+type Transformer interface {
+	Translate(dx, dy float64) Shape
+	Scale(factor float64) (Shape, error)
+	Rotate(radians float64) Shape
+}
+
+type Validator interface {
+	Validate() error
+}
+
+var ErrInvalidShape = errors.New("invalid shape")
+
+// Validate checks a shape if it knows how to check itself.
+func Validate(s Shape) error {
+	if v, ok := s.(Validator); ok {
+		return v.Validate()
+	}
+	return nil
+}
+
+func invalid(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalidShape, fmt.Sprintf(format, args...))
+}
+
+// positive rejects negative, zero, NaN and infinite dimensions.
+func positive(name string, v float64) error {
+	if !(v > 0) || math.IsInf(v, 0) {
+		return invalid("%s must be positive, got %v", name, v)
+	}
+	return nil
+}
+
+var ErrUnsupportedShape = errors.New("shape does not support intersection tests")
+
+// ellipseSegments is how finely an ellipse is approximated for intersection tests.
+const ellipseSegments = 128
+
+// outline returns the shape's boundary as a polygon; only Ellipse is approximated.
+func outline(s Shape) ([]Point, bool) {
+	switch v := s.(type) {
+	case Rectangle:
+		return v.vertices(), true
+	case Triangle:
+		return []Point{v.A, v.B, v.C}, true
+	case RegularPolygon:
+		return v.vertices(), true
+	case Polygon:
+		return v.Vertices, true
+	case Ellipse:
+		return v.approximate(ellipseSegments), true
+	}
+	return nil, false
+}
+
+// Intersects reports whether two shapes overlap or touch. Circles are tested exactly;
+// ellipses are approximated by a fine polygon. Both shapes are validated first.
+func Intersects(a, b Shape) (bool, error) {
+	if err := Validate(a); err != nil {
+		return false, err
+	}
+	if err := Validate(b); err != nil {
+		return false, err
+	}
+
+	ca, aIsCircle := a.(Circle)
+	cb, bIsCircle := b.(Circle)
+
+	switch {
+	case aIsCircle && bIsCircle:
+		return ca.Center.distance(cb.Center) <= ca.Radius+cb.Radius, nil
+	case aIsCircle:
+		return circleIntersectsShape(ca, b)
+	case bIsCircle:
+		return circleIntersectsShape(cb, a)
+	}
+
+	pa, okA := outline(a)
+	pb, okB := outline(b)
+	if !okA || !okB {
+		return false, ErrUnsupportedShape
+	}
+
+	if !bboxOf(pa).Intersects(bboxOf(pb)) {
+		return false, nil
+	}
+	for i := range pa {
+		for j := range pb {
+			if segmentsIntersect(pa[i], pa[(i+1)%len(pa)], pb[j], pb[(j+1)%len(pb)]) {
+				return true, nil
+			}
+		}
+	}
+	// no crossing edges: either one is inside the other, or they're apart
+	return pointInPolygon(pa[0], pb) || pointInPolygon(pb[0], pa), nil
+}
+
+func circleIntersectsShape(c Circle, s Shape) (bool, error) {
+	poly, ok := outline(s)
+	if !ok {
+		return false, ErrUnsupportedShape
+	}
+
+	if pointInPolygon(c.Center, poly) {
+		return true, nil
+	}
+	for i := range poly {
+		if segmentDistance(c.Center, poly[i], poly[(i+1)%len(poly)]) <= c.Radius {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// cross is the z component of (b-a) x (c-a): >0 if c is left of a->b, <0 if right, 0 if collinear.
+func cross(a, b, c Point) float64 {
+	return (b.X-a.X)*(c.Y-a.Y) - (b.Y-a.Y)*(c.X-a.X)
+}
+
+func onSegment(p, a, b Point) bool {
+	return cross(a, b, p) == 0 &&
+		p.X >= math.Min(a.X, b.X) && p.X <= math.Max(a.X, b.X) &&
+		p.Y >= math.Min(a.Y, b.Y) && p.Y <= math.Max(a.Y, b.Y)
+}
+
+func segmentsIntersect(p1, p2, q1, q2 Point) bool {
+	d1, d2 := cross(q1, q2, p1), cross(q1, q2, p2)
+	d3, d4 := cross(p1, p2, q1), cross(p1, p2, q2)
+
+	if ((d1 > 0 && d2 < 0) || (d1 < 0 && d2 > 0)) && ((d3 > 0 && d4 < 0) || (d3 < 0 && d4 > 0)) {
+		return true
+	}
+	return onSegment(p1, q1, q2) || onSegment(p2, q1, q2) || onSegment(q1, p1, p2) || onSegment(q2, p1, p2)
+}
+
+// segmentDistance is the distance from p to the segment a-b.
+func segmentDistance(p, a, b Point) float64 {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	if dx == 0 && dy == 0 {
+		return p.distance(a)
+	}
+	t := ((p.X-a.X)*dx + (p.Y-a.Y)*dy) / (dx*dx + dy*dy)
+	t = math.Max(0, math.Min(1, t))
+	return p.distance(Point{a.X + t*dx, a.Y + t*dy})
+}
+
+// pointInPolygon uses ray casting; points on an edge count as inside.
+func pointInPolygon(p Point, poly []Point) bool {
+	inside := false
+	for i, j := 0, len(poly)-1; i < len(poly); j, i = i, i+1 {
+		a, b := poly[j], poly[i]
+		if onSegment(p, a, b) {
+			return true
+		}
+		if (a.Y > p.Y) != (b.Y > p.Y) && p.X < (b.X-a.X)*(p.Y-a.Y)/(b.Y-a.Y)+a.X {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// shoelace returns the signed area of a polygon (positive when counter-clockwise).
+func shoelace(poly []Point) float64 {
+	sum := 0.0
+	for i := range poly {
+		j := (i + 1) % len(poly)
+		sum += poly[i].X*poly[j].Y - poly[j].X*poly[i].Y
+	}
+	return sum / 2
+}
+
+func pathLength(poly []Point) float64 {
+	total := 0.0
+	for i := range poly {
+		total += poly[i].distance(poly[(i+1)%len(poly)])
+	}
+	return total
+}
+
+func transformPoints(points []Point, fn func(Point) Point) []Point {
+	out := make([]Point, len(points))
+	for i, p := range points {
+		out[i] = fn(p)
+	}
+	return out
+}
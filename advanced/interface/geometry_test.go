@@ -0,0 +1,44 @@
+package interface_example
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestZeroValueShapesDoNotPanic(t *testing.T) {
+	for _, s := range []Shape{Polygon{}, RegularPolygon{}, RegularPolygon{Sides: -1}} {
+		if got := s.(Bounded).BoundingBox(); got != (BBox{}) {
+			t.Errorf("%T.BoundingBox() = %v, want the empty box", s, got)
+		}
+		if _, err := Intersects(s, Circle{Radius: 1}); !errors.Is(err, ErrInvalidShape) {
+			t.Errorf("Intersects(%T{}, circle) = %v, want ErrInvalidShape", s, err)
+		}
+	}
+
+	// a zero-area polygon has no area-weighted centroid
+	flat := Polygon{Vertices: []Point{{0, 0}, {2, 0}, {4, 0}}}
+	if got := flat.Rotate(1).(Polygon).Vertices[1]; got != (Point{2, 0}) {
+		t.Errorf("rotating a flat polygon moved its middle vertex to %v", got)
+	}
+}
+
+func TestScaleRejectsNonPositiveFactors(t *testing.T) {
+	shapes := []Transformer{
+		Rectangle{Width: 1, Height: 1},
+		Circle{Radius: 1},
+		Triangle{A: Point{0, 0}, B: Point{1, 0}, C: Point{0, 1}},
+		RegularPolygon{Sides: 5, Radius: 1},
+		Ellipse{RX: 1, RY: 2},
+		Polygon{Vertices: []Point{{0, 0}, {1, 0}, {0, 1}}},
+	}
+	for _, s := range shapes {
+		for _, factor := range []float64{0, -2} {
+			if _, err := s.Scale(factor); !errors.Is(err, ErrInvalidShape) {
+				t.Errorf("%T.Scale(%v) = %v, want ErrInvalidShape", s, factor, err)
+			}
+		}
+		if _, err := s.Scale(2); err != nil {
+			t.Errorf("%T.Scale(2) = %v", s, err)
+		}
+	}
+}
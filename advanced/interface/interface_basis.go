@@ -13,7 +13,9 @@ type Shape interface {
 }
 
 type Rectangle struct {
-	Width, Height float64
+	Origin Point   `json:"origin"` // bottom-left corner, the zero value puts it at (0, 0)
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
 }
 
 func (r Rectangle) Area() float64 {
@@ -25,7 +27,8 @@ func (r Rectangle) Perimeter() float64 {
 }
 
 type Circle struct {
-	Radius float64
+	Center Point   `json:"center"`
+	Radius float64 `json:"radius"`
 }
 
 func (c Circle) Area() float64 {
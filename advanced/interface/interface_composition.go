@@ -1,6 +1,9 @@
 package interface_example
 
-import "fmt"
+import (
+	"fmt"
+	"io"
+)
 
 type Reader interface {
 	Read(p []byte) (n int, err error)
@@ -15,6 +18,41 @@ type ReadWriter interface {
 	Writer
 }
 
+// Seek whence values, same as io.SeekStart, io.SeekCurrent and io.SeekEnd.
+type Seeker interface {
+	Seek(offset int64, whence int) (int64, error)
+}
+
+type Closer interface {
+	Close() error
+}
+
+type ReadCloser interface {
+	Reader
+	Closer
+}
+
+type WriteCloser interface {
+	Writer
+	Closer
+}
+
+type ReadWriteSeeker interface {
+	ReadWriter
+	Seeker
+}
+
+type ReadWriteCloser interface {
+	ReadWriter
+	Closer
+}
+
+// ReadWriteSeekCloser is what a real file offers; MemFile, OSFile and ThroughFile all implement it.
+type ReadWriteSeekCloser interface {
+	ReadWriteSeeker
+	Closer
+}
+
 type File struct {
 	Name string
 }
@@ -29,7 +67,33 @@ func (f *File) Write(p []byte) (n int, err error) {
 	return len(p), nil
 }
 
-func Process(data []byte, rw ReadWriter) {
-	rw.Write(data)
-	rw.Read(data)
+// Process writes data to rw and reads the same number of bytes back. When rw can seek,
+// it rewinds to where the write started first, so a real file returns what was written.
+func Process(data []byte, rw ReadWriter) ([]byte, error) {
+	var start int64
+	seeker, canSeek := rw.(Seeker)
+	if canSeek {
+		pos, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, err
+		}
+		start = pos
+	}
+
+	n, err := rw.Write(data)
+	if err != nil {
+		return nil, err
+	}
+	if n < len(data) {
+		return nil, io.ErrShortWrite
+	}
+
+	if canSeek {
+		if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+			return nil, err
+		}
+	}
+	out := make([]byte, len(data))
+	n, err = io.ReadFull(rw, out)
+	return out[:n], err
 }
@@ -1,6 +1,7 @@
 package interface_example
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -10,9 +11,9 @@ import (
 type EmptyInterface interface{}
 
 // Example function that accepts an empty interface
-// generically and prints the value
+// generically and prints the value; PrettyLine walks it with reflection
 func PrintValue(v EmptyInterface) string {
-	return fmt.Sprintf("Value: %v", v)
+	return fmt.Sprintf("Value: %s", PrettyLine(v))
 }
 
 // Type assertion examples
@@ -34,7 +35,7 @@ func TypeSwitch(v EmptyInterface) {
 	case []int:
 		fmt.Println("v is type of integer slice:", v)
 	default:
-		fmt.Println("unknown type")
+		fmt.Printf("v is type of %T: %s\n", v, PrettyLine(v))
 	}
 }
 
@@ -93,7 +94,17 @@ func ParseJSON(jsonData []byte) (User, error) {
 	return user, nil
 }
 
-// Outgoing Publishing with unknown payload using empty interface
+// Outgoing Publishing with unknown payload using empty interface; subscribers of DefaultBus receive it,
+// or, after UseOutbox, the event is stored in the outbox first and delivered from there
 func Publish(eventName string, payload interface{}) {
 	fmt.Printf("event=%s payload=%v\n", eventName, payload)
+	if o := defaultOutbox.Load(); o != nil {
+		if _, err := o.Publish(eventName, payload); err != nil {
+			fmt.Println(err)
+		}
+		return
+	}
+	if err := DefaultBus.Publish(context.Background(), eventName, payload); err != nil {
+		fmt.Println(err)
+	}
 }
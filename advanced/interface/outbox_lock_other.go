@@ -0,0 +1,26 @@
+//go:build !unix
+
+package interface_example
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// lockDir creates the outbox's lock file exclusively and removes it on unlock.
+// Without flock a crash leaves the file behind; delete it by hand once nothing runs.
+func lockDir(dir string) (unlock func() error, err error) {
+	path := filepath.Join(dir, outboxLockFile)
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o644)
+	if errors.Is(err, os.ErrExist) {
+		return nil, fmt.Errorf("%w: %s", ErrOutboxLocked, dir)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return func() error {
+		return errors.Join(f.Close(), os.Remove(path))
+	}, nil
+}
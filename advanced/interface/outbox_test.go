@@ -0,0 +1,169 @@
+package interface_example
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestReplayOutbox(t *testing.T) {
+	dir := t.TempDir()
+	down := func(context.Context, OutboxEvent) error { return errors.New("consumer down") }
+
+	o, err := OpenOutbox(dir, down, WithOutboxMaxAttempts(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 4 {
+		if _, err := o.Publish("tick", i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var got []int64
+	collect := func(_ context.Context, e OutboxEvent) error {
+		got = append(got, e.Offset)
+		return nil
+	}
+	if _, err := ReplayOutbox(context.Background(), dir, 1, collect); !errors.Is(err, ErrOutboxLocked) {
+		t.Fatalf("ReplayOutbox next to an open outbox = %v, want ErrOutboxLocked", err)
+	}
+	if err := o.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := ReplayOutbox(context.Background(), dir, 3, collect)
+	if err != nil || n != 2 || !slices.Equal(got, []int64{3, 4}) {
+		t.Fatalf("ReplayOutbox(from 3) = %d, %v, offsets %v; want 2, nil, [3 4]", n, err, got)
+	}
+
+	// the replay wrote no markers, so every event is still pending for the outbox itself
+	o, err = OpenOutbox(dir, down, WithOutboxMaxAttempts(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer o.Close()
+	if p := o.Pending(); p != 4 {
+		t.Errorf("pending after replay = %d, want 4", p)
+	}
+}
+
+func TestReplayOutboxStopsAtFailure(t *testing.T) {
+	dir := t.TempDir()
+	o, err := OpenOutbox(dir, func(context.Context, OutboxEvent) error { return errors.New("consumer down") })
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 3 {
+		o.Publish("tick", i)
+	}
+	o.Close()
+
+	failAt2 := func(_ context.Context, e OutboxEvent) error {
+		if e.Offset == 2 {
+			return errors.New("rejected")
+		}
+		return nil
+	}
+	n, err := ReplayOutbox(context.Background(), dir, 1, failAt2)
+	if n != 1 || err == nil {
+		t.Fatalf("ReplayOutbox = %d, %v; want 1 and the error for event 2", n, err)
+	}
+}
+
+func TestJSONLinesSender(t *testing.T) {
+	var out bytes.Buffer
+	send := JSONLinesSender(&out)
+	for i := range int64(2) {
+		if err := send(context.Background(), OutboxEvent{Offset: i + 1, Topic: "tick", Payload: json.RawMessage(`{}`)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dec := json.NewDecoder(&out)
+	for want := int64(1); want <= 2; want++ {
+		var e OutboxEvent
+		if err := dec.Decode(&e); err != nil || e.Offset != want || e.Topic != "tick" {
+			t.Fatalf("line %d = %+v, %v", want, e, err)
+		}
+	}
+}
+
+// failingLog writes only half of each line and then fails, the way a full disk does.
+type failingLog struct {
+	logFile
+	failWrites    int
+	failTruncates bool
+}
+
+func (f *failingLog) Write(p []byte) (int, error) {
+	if f.failWrites > 0 {
+		f.failWrites--
+		n, _ := f.logFile.Write(p[:len(p)/2])
+		return n, errors.New("disk full")
+	}
+	return f.logFile.Write(p)
+}
+
+func (f *failingLog) Truncate(size int64) error {
+	if f.failTruncates {
+		return errors.New("read-only filesystem")
+	}
+	return f.logFile.Truncate(size)
+}
+
+// openStalled opens an outbox whose deliveries never finish, so everything stays pending.
+func openStalled(t *testing.T, dir string) *Outbox {
+	t.Helper()
+	stalled := func(ctx context.Context, _ OutboxEvent) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	o, err := OpenOutbox(dir, stalled)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return o
+}
+
+func TestOutboxRollsBackTornAppend(t *testing.T) {
+	dir := t.TempDir()
+	o := openStalled(t, dir)
+	o.mu.Lock()
+	o.log = &failingLog{logFile: o.log, failWrites: 1}
+	o.mu.Unlock()
+
+	if _, err := o.Publish("tick", 1); err == nil {
+		t.Fatal("Publish succeeded through a failing write")
+	}
+	offset, err := o.Publish("tick", 2)
+	if err != nil || offset != 1 {
+		t.Fatalf("Publish after the failure = %d, %v; want offset 1", offset, err)
+	}
+	o.Close()
+
+	// the torn half line is gone, so the log opens again with just the second event
+	o = openStalled(t, dir)
+	defer o.Close()
+	if p := o.Pending(); p != 1 {
+		t.Errorf("pending after reopening = %d, want 1", p)
+	}
+}
+
+func TestOutboxFailsWhenRollbackFails(t *testing.T) {
+	o := openStalled(t, t.TempDir())
+	defer o.Close()
+	o.mu.Lock()
+	o.log = &failingLog{logFile: o.log, failWrites: 1, failTruncates: true}
+	o.mu.Unlock()
+
+	if _, err := o.Publish("tick", 1); !errors.Is(err, ErrOutboxFailed) {
+		t.Fatalf("Publish with a failed rollback = %v, want ErrOutboxFailed", err)
+	}
+	if _, err := o.Publish("tick", 2); !errors.Is(err, ErrOutboxFailed) {
+		t.Errorf("Publish on a failed outbox = %v, want ErrOutboxFailed", err)
+	}
+}
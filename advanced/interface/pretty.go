@@ -0,0 +1,364 @@
+package interface_example
+
+// PrintValue leans on %v and TypeSwitch only knows a few types. With reflection a single
+// function can walk any value: reflect.ValueOf(v).Kind() says whether it's a struct, map,
+// slice, pointer... and the Value gives access to fields, keys and elements.
+//
+// The printer's output is stable enough to diff or grep: map keys are sorted, long slices
+// and maps are truncated, and a pointer that leads back to a value already being printed is
+// shown as <cycle> instead of recursing forever. Types can get their own formatter, and
+// fmt.Stringer / error values print through their String / Error method.
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+type Printer struct {
+	indent   string // "" prints everything on one line
+	maxItems int    // per slice/array/map; 0 means no limit
+	maxDepth int    // 0 means no limit
+
+	mu         sync.RWMutex
+	formatters map[reflect.Type]func(reflect.Value) string
+}
+
+type PrinterOption func(*Printer)
+
+// WithIndent sets the indentation per level; "" gives the compact single-line form.
+func WithIndent(indent string) PrinterOption {
+	return func(p *Printer) {
+		p.indent = indent
+	}
+}
+
+func WithMaxItems(n int) PrinterOption {
+	return func(p *Printer) {
+		p.maxItems = n
+	}
+}
+
+func WithMaxDepth(n int) PrinterOption {
+	return func(p *Printer) {
+		p.maxDepth = n
+	}
+}
+
+// NewPrinter returns an indented printer; time.Time prints as RFC 3339.
+func NewPrinter(opts ...PrinterOption) *Printer {
+	p := &Printer{indent: "  ", formatters: make(map[reflect.Type]func(reflect.Value) string)}
+	for _, opt := range opts {
+		opt(p)
+	}
+	RegisterFormatter(p, func(t time.Time) string {
+		return t.Format(time.RFC3339)
+	})
+	return p
+}
+
+// RegisterFormatter makes p print every T with fn, wherever it appears in a value.
+func RegisterFormatter[T any](p *Printer, fn func(T) string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.formatters[reflect.TypeFor[T]()] = func(v reflect.Value) string {
+		return fn(v.Interface().(T))
+	}
+}
+
+var (
+	prettyPrinter = NewPrinter(WithMaxItems(100))
+	linePrinter   = NewPrinter(WithIndent(""), WithMaxItems(20))
+)
+
+// Pretty formats v over several indented lines, for humans.
+func Pretty(v any) string {
+	return prettyPrinter.Sprint(v)
+}
+
+// PrettyLine formats v on one line, for logs.
+func PrettyLine(v any) string {
+	return linePrinter.Sprint(v)
+}
+
+func (p *Printer) Sprint(v any) string {
+	var b strings.Builder
+	p.format(&b, reflect.ValueOf(v), 0, map[uintptr]bool{})
+	return b.String()
+}
+
+func (p *Printer) Fprint(w io.Writer, v any) error {
+	_, err := io.WriteString(w, p.Sprint(v)+"\n")
+	return err
+}
+
+func (p *Printer) format(b *strings.Builder, v reflect.Value, depth int, visiting map[uintptr]bool) {
+	if !v.IsValid() {
+		b.WriteString("nil")
+		return
+	}
+	if s, ok := p.custom(v); ok {
+		b.WriteString(s)
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		b.WriteString(strconv.FormatBool(v.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		b.WriteString(strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		b.WriteString(strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		b.WriteString(strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()))
+	case reflect.Complex64, reflect.Complex128:
+		b.WriteString(strconv.FormatComplex(v.Complex(), 'g', -1, v.Type().Bits()))
+	case reflect.String:
+		b.WriteString(strconv.Quote(v.String()))
+
+	case reflect.Interface:
+		p.format(b, v.Elem(), depth, visiting)
+
+	case reflect.Pointer:
+		if v.IsNil() {
+			b.WriteString("nil")
+			return
+		}
+		if visiting[v.Pointer()] {
+			fmt.Fprintf(b, "<cycle %s>", v.Type())
+			return
+		}
+		visiting[v.Pointer()] = true
+		defer delete(visiting, v.Pointer())
+		b.WriteByte('&')
+		p.format(b, v.Elem(), depth, visiting)
+
+	case reflect.Struct:
+		if p.tooDeep(b, depth) {
+			return
+		}
+		t := v.Type()
+		items := make([]string, 0, t.NumField())
+		for i := range t.NumField() {
+			items = append(items, t.Field(i).Name+": "+p.child(v.Field(i), depth, visiting))
+		}
+		name := t.String()
+		if t.Name() == "" {
+			name = "" // anonymous struct: its type string would spell out every field again
+		}
+		p.writeItems(b, name+"{", "}", items, 0, depth)
+
+	case reflect.Map:
+		if v.IsNil() {
+			b.WriteString("nil")
+			return
+		}
+		if visiting[v.Pointer()] {
+			fmt.Fprintf(b, "<cycle %s>", v.Type())
+			return
+		}
+		if p.tooDeep(b, depth) {
+			return
+		}
+		visiting[v.Pointer()] = true
+		defer delete(visiting, v.Pointer())
+
+		keys := v.MapKeys()
+		slices.SortFunc(keys, compareKeys)
+		shown, hidden := p.truncate(len(keys))
+		items := make([]string, 0, shown)
+		for _, k := range keys[:shown] {
+			items = append(items, p.child(k, depth, visiting)+": "+p.child(v.MapIndex(k), depth, visiting))
+		}
+		p.writeItems(b, "{", "}", items, hidden, depth)
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			b.WriteString("nil")
+			return
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 && v.Kind() == reflect.Slice && utf8.Valid(v.Bytes()) {
+			fmt.Fprintf(b, "[]byte(%q)", v.Bytes())
+			return
+		}
+		if p.tooDeep(b, depth) {
+			return
+		}
+		// a slice can contain itself through an []any element
+		if v.Kind() == reflect.Slice && v.Len() > 0 {
+			if visiting[v.Pointer()] {
+				fmt.Fprintf(b, "<cycle %s>", v.Type())
+				return
+			}
+			visiting[v.Pointer()] = true
+			defer delete(visiting, v.Pointer())
+		}
+
+		shown, hidden := p.truncate(v.Len())
+		items := make([]string, 0, shown)
+		for i := range shown {
+			items = append(items, p.child(v.Index(i), depth, visiting))
+		}
+		p.writeItems(b, "[", "]", items, hidden, depth)
+
+	default: // chan, func, unsafe.Pointer
+		if v.IsNil() {
+			fmt.Fprintf(b, "%s(nil)", v.Type())
+		} else {
+			fmt.Fprintf(b, "%s(%#x)", v.Type(), v.Pointer())
+		}
+	}
+}
+
+// custom handles registered formatters, then fmt.Stringer and error.
+func (p *Printer) custom(v reflect.Value) (string, bool) {
+	if !v.CanInterface() {
+		return "", false // unexported struct field
+	}
+
+	p.mu.RLock()
+	fn, ok := p.formatters[v.Type()]
+	p.mu.RUnlock()
+	if ok {
+		return fn(v), true
+	}
+
+	if v.Kind() == reflect.Pointer && v.IsNil() || v.Kind() == reflect.Interface {
+		return "", false
+	}
+	switch x := v.Interface().(type) {
+	case error:
+		return strconv.Quote(x.Error()), true
+	case fmt.Stringer:
+		return x.String(), true
+	}
+	return "", false
+}
+
+func (p *Printer) child(v reflect.Value, depth int, visiting map[uintptr]bool) string {
+	var b strings.Builder
+	p.format(&b, v, depth+1, visiting)
+	return b.String()
+}
+
+func (p *Printer) tooDeep(b *strings.Builder, depth int) bool {
+	if p.maxDepth > 0 && depth >= p.maxDepth {
+		b.WriteString("...")
+		return true
+	}
+	return false
+}
+
+func (p *Printer) truncate(n int) (shown, hidden int) {
+	if p.maxItems > 0 && n > p.maxItems {
+		return p.maxItems, n - p.maxItems
+	}
+	return n, 0
+}
+
+func (p *Printer) writeItems(b *strings.Builder, open, close string, items []string, hidden, depth int) {
+	if hidden > 0 {
+		items = append(items, fmt.Sprintf("... (%d more)", hidden))
+	}
+	b.WriteString(open)
+	if len(items) == 0 {
+		b.WriteString(close)
+		return
+	}
+
+	if p.indent == "" {
+		b.WriteString(strings.Join(items, ", "))
+		b.WriteString(close)
+		return
+	}
+
+	inner := strings.Repeat(p.indent, depth+1)
+	for _, item := range items {
+		b.WriteString("\n" + inner + item + ",")
+	}
+	b.WriteString("\n" + strings.Repeat(p.indent, depth) + close)
+}
+
+// compareKeys orders numbers and strings naturally, anything else by its %v form.
+func compareKeys(a, b reflect.Value) int {
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmp.Compare(a.Int(), b.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return cmp.Compare(a.Uint(), b.Uint())
+	case reflect.Float32, reflect.Float64:
+		return cmp.Compare(a.Float(), b.Float())
+	case reflect.String:
+		return cmp.Compare(a.String(), b.String())
+	}
+	return cmp.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+type Money int64 // cents
+
+type treeNode struct {
+	Name     string
+	Parent   *treeNode
+	Children []*treeNode
+}
+
+type Order struct {
+	ID       int
+	Customer *User
+	Items    map[string]int
+	Total    Money
+	Notes    []string
+	Placed   time.Time
+	Extra    any
+	internal string
+}
+
+func PrettyTest() {
+	order := Order{
+		ID:       1001,
+		Customer: &User{ID: 1, Name: "Alice", Age: 30},
+		Items:    map[string]int{"pen": 3, "notebook": 1, "bag": 2},
+		Total:    2599,
+		Notes:    []string{"gift wrap"},
+		Placed:   time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC),
+		Extra:    map[int][]float64{2: {0.5}, 1: {1, 2.5}},
+		internal: "unexported fields are printed too",
+	}
+
+	p := NewPrinter(WithMaxItems(5))
+	RegisterFormatter(p, func(m Money) string {
+		return fmt.Sprintf("$%d.%02d", m/100, m%100)
+	})
+	fmt.Println(p.Sprint(order))
+
+	// Compact mode for log lines
+	line := NewPrinter(WithIndent(""), WithMaxItems(5))
+	RegisterFormatter(line, func(m Money) string {
+		return fmt.Sprintf("$%d.%02d", m/100, m%100)
+	})
+	fmt.Println("log:", line.Sprint(order))
+
+	// Long slices are truncated
+	fmt.Println(PrettyLine(make([]int, 50)))
+
+	// Parent pointers form cycles; they're detected instead of recursing forever
+	root := &treeNode{Name: "root"}
+	root.Children = []*treeNode{{Name: "a", Parent: root}, {Name: "b", Parent: root}}
+	fmt.Println(PrettyLine(root))
+
+	self := map[string]any{"name": "loop"}
+	self["self"] = self
+	fmt.Println(PrettyLine(self))
+
+	// Depth limit, Stringers and errors
+	deep := NewPrinter(WithIndent(""), WithMaxDepth(2))
+	fmt.Println(deep.Sprint([]any{[]any{[]any{1}}}))
+	fmt.Println(PrettyLine([]any{90 * time.Second, fmt.Errorf("boom"), nil, struct{ Name string }{"Gopher"}}))
+}
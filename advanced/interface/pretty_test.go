@@ -0,0 +1,126 @@
+package interface_example
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestPrettyLine(t *testing.T) {
+	tests := []struct {
+		name string
+		v    any
+		want string
+	}{
+		{"nil", nil, "nil"},
+		{"bool", true, "true"},
+		{"int", -3, "-3"},
+		{"uint", uint8(7), "7"},
+		{"float", 2.5, "2.5"},
+		{"string", `q"s`, `"q\"s"`},
+		{"nil slice", []int(nil), "nil"},
+		{"empty slice", []int{}, "[]"},
+		{"array", [2]int{1, 2}, "[1, 2]"},
+		{"text bytes", []byte("hi"), `[]byte("hi")`},
+		{"binary bytes", []byte{0xff}, "[255]"},
+		{"map keys sorted", map[string]int{"b": 2, "a": 1, "c": 3}, `{"a": 1, "b": 2, "c": 3}`},
+		{"int keys sorted by value", map[int]bool{10: true, 2: false}, "{2: false, 10: true}"},
+		{"nil map", map[string]int(nil), "nil"},
+		{"nil pointer", (*User)(nil), "nil"},
+		{"struct", User{ID: 1, Name: "A", Age: 2}, `interface_example.User{ID: 1, Name: "A", Age: 2}`},
+		{"pointer", &User{ID: 1}, `&interface_example.User{ID: 1, Name: "", Age: 0}`},
+		{"anonymous struct", struct{ X int }{1}, "{X: 1}"},
+		{"unexported field", struct{ n int }{5}, "{n: 5}"},
+		{"Stringer", 90 * time.Second, "1m30s"},
+		{"error", errors.New("boom"), `"boom"`},
+		{"time", time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC), "2026-03-14T09:30:00Z"},
+		{"nil chan", (chan int)(nil), "chan int(nil)"},
+	}
+	p := NewPrinter(WithIndent(""))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.Sprint(tt.v); got != tt.want {
+				t.Errorf("Sprint = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrettyCycles(t *testing.T) {
+	p := NewPrinter(WithIndent(""))
+
+	root := &treeNode{Name: "root"}
+	root.Children = []*treeNode{{Name: "a", Parent: root}}
+	want := `&interface_example.treeNode{Name: "root", Parent: nil, Children: [&interface_example.treeNode{Name: "a", Parent: <cycle *interface_example.treeNode>, Children: nil}]}`
+	if got := p.Sprint(root); got != want {
+		t.Errorf("tree = %s\nwant %s", got, want)
+	}
+
+	self := map[string]any{"n": 1}
+	self["self"] = self
+	if got, want := p.Sprint(self), `{"n": 1, "self": <cycle map[string]interface {}>}`; got != want {
+		t.Errorf("map = %s, want %s", got, want)
+	}
+
+	s := []any{1}
+	s[0] = s
+	if got, want := p.Sprint(s), "[<cycle []interface {}>]"; got != want {
+		t.Errorf("slice = %s, want %s", got, want)
+	}
+
+	// the same pointer twice side by side isn't a cycle
+	shared := &User{ID: 1}
+	one := `&interface_example.User{ID: 1, Name: "", Age: 0}`
+	if got, want := p.Sprint([]*User{shared, shared}), "["+one+", "+one+"]"; got != want {
+		t.Errorf("shared = %s, want %s", got, want)
+	}
+}
+
+func TestPrettyLimits(t *testing.T) {
+	short := NewPrinter(WithIndent(""), WithMaxItems(2))
+	if got, want := short.Sprint([]int{1, 2, 3, 4}), "[1, 2, ... (2 more)]"; got != want {
+		t.Errorf("slice = %s, want %s", got, want)
+	}
+	if got, want := short.Sprint(map[string]int{"a": 1, "b": 2, "c": 3}), `{"a": 1, "b": 2, ... (1 more)}`; got != want {
+		t.Errorf("map = %s, want %s", got, want)
+	}
+
+	shallow := NewPrinter(WithIndent(""), WithMaxDepth(2))
+	if got, want := shallow.Sprint([]any{[]any{[]any{1}}}), "[[...]]"; got != want {
+		t.Errorf("deep = %s, want %s", got, want)
+	}
+}
+
+func TestPrettyIndent(t *testing.T) {
+	got := NewPrinter().Sprint(map[string][]int{"a": {1, 2}, "b": {}})
+	want := `{
+  "a": [
+    1,
+    2,
+  ],
+  "b": [],
+}`
+	if got != want {
+		t.Errorf("Sprint =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRegisterFormatter(t *testing.T) {
+	p := NewPrinter(WithIndent(""))
+	RegisterFormatter(p, func(m Money) string {
+		return fmt.Sprintf("$%d.%02d", m/100, m%100)
+	})
+	got := p.Sprint(struct {
+		Total Money
+		Items []Money
+	}{2599, []Money{5, 100}})
+	if want := "{Total: $25.99, Items: [$0.05, $1.00]}"; got != want {
+		t.Errorf("Sprint = %s, want %s", got, want)
+	}
+
+	// formatters belong to their printer
+	if got := PrettyLine(Money(2599)); got != "2599" {
+		t.Errorf("PrettyLine(Money) = %s, want 2599", got)
+	}
+}
@@ -0,0 +1,420 @@
+package interface_example
+
+// Three real implementations of ReadWriteSeekCloser, all following the io contracts:
+//
+// Read may return fewer bytes than asked for (a partial read); at the end it returns 0, io.EOF.
+// Write either writes everything or returns an error.
+// Seek moves the offset both Read and Write use; seeking past the end is allowed and
+// a Write there fills the gap with zeros, like a real file.
+// After Close every method fails.
+//
+// MemFile keeps the content in memory, OSFile wraps *os.File, and ThroughFile is a
+// read-through/write-through cache in front of any other ReadWriteSeeker.
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var (
+	ErrFileClosed     = errors.New("file already closed")
+	ErrNegativeOffset = errors.New("negative offset")
+)
+
+// seekOffset resolves a Seek call against the current offset and size.
+func seekOffset(offset int64, whence int, current, size int64) (int64, error) {
+	var base int64
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		base = current
+	case io.SeekEnd:
+		base = size
+	default:
+		return current, fmt.Errorf("invalid whence %d", whence)
+	}
+	if base+offset < 0 {
+		return current, ErrNegativeOffset
+	}
+	return base + offset, nil
+}
+
+type MemFile struct {
+	name   string
+	mu     sync.Mutex
+	data   []byte
+	offset int64
+	closed bool
+}
+
+// NewMemFile returns an in-memory file holding a copy of data, positioned at the start.
+func NewMemFile(name string, data []byte) *MemFile {
+	return &MemFile{name: name, data: append([]byte(nil), data...)}
+}
+
+func (f *MemFile) Name() string {
+	return f.name
+}
+
+func (f *MemFile) Read(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return 0, ErrFileClosed
+	}
+	n, err := f.readAt(p, f.offset)
+	f.offset += int64(n)
+	return n, err
+}
+
+// ReadAt reads from off without moving the offset; unlike Read it only returns fewer than
+// len(p) bytes together with an error.
+func (f *MemFile) ReadAt(p []byte, off int64) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return 0, ErrFileClosed
+	}
+	if off < 0 {
+		return 0, ErrNegativeOffset
+	}
+	n, err := f.readAt(p, off)
+	if err == nil && n < len(p) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (f *MemFile) readAt(p []byte, off int64) (int, error) {
+	if off >= int64(len(f.data)) {
+		if len(p) == 0 {
+			return 0, nil
+		}
+		return 0, io.EOF
+	}
+	return copy(p, f.data[off:]), nil
+}
+
+func (f *MemFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return 0, ErrFileClosed
+	}
+
+	end := f.offset + int64(len(p))
+	if end > int64(len(f.data)) {
+		f.data = append(f.data, make([]byte, end-int64(len(f.data)))...)
+	}
+	copy(f.data[f.offset:], p)
+	f.offset = end
+	return len(p), nil
+}
+
+func (f *MemFile) Seek(offset int64, whence int) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return 0, ErrFileClosed
+	}
+	pos, err := seekOffset(offset, whence, f.offset, int64(len(f.data)))
+	f.offset = pos
+	return pos, err
+}
+
+// Truncate changes the size; growing pads with zeros. The offset stays where it is.
+func (f *MemFile) Truncate(size int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return ErrFileClosed
+	}
+	if size < 0 {
+		return ErrNegativeOffset
+	}
+	if size <= int64(len(f.data)) {
+		f.data = f.data[:size]
+	} else {
+		f.data = append(f.data, make([]byte, size-int64(len(f.data)))...)
+	}
+	return nil
+}
+
+func (f *MemFile) Size() int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return int64(len(f.data))
+}
+
+// Bytes returns a copy of the content; it still works after Close.
+func (f *MemFile) Bytes() []byte {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]byte(nil), f.data...)
+}
+
+func (f *MemFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return ErrFileClosed
+	}
+	f.closed = true
+	return nil
+}
+
+// OSFile is a file on disk. *os.File already has the right methods; the wrapper is here to
+// show the same interfaces backed by the operating system.
+type OSFile struct {
+	f *os.File
+}
+
+// OpenOSFile opens path for reading and writing, creating it if needed.
+func OpenOSFile(path string) (*OSFile, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &OSFile{f: f}, nil
+}
+
+func (f *OSFile) Name() string                                 { return f.f.Name() }
+func (f *OSFile) Read(p []byte) (int, error)                   { return f.f.Read(p) }
+func (f *OSFile) ReadAt(p []byte, off int64) (int, error)      { return f.f.ReadAt(p, off) }
+func (f *OSFile) Write(p []byte) (int, error)                  { return f.f.Write(p) }
+func (f *OSFile) Seek(offset int64, whence int) (int64, error) { return f.f.Seek(offset, whence) }
+func (f *OSFile) Truncate(size int64) error                    { return f.f.Truncate(size) }
+func (f *OSFile) Sync() error                                  { return f.f.Sync() }
+func (f *OSFile) Close() error                                 { return f.f.Close() }
+
+// ThroughFile caches another file in fixed-size blocks. Reads are served from the cache and
+// fill it on a miss (read-through); writes go to the backing file right away and update
+// cached blocks (write-through), so the backing file is never stale.
+type ThroughFile struct {
+	backing   ReadWriteSeeker
+	blockSize int64
+
+	mu     sync.Mutex
+	blocks map[int64][]byte // block index -> content, the last block may be short
+	offset int64
+	size   int64
+	closed bool
+	hits   int
+	misses int
+}
+
+const defaultBlockSize = 4096
+
+// NewThroughFile wraps backing; blockSize 0 means 4 KiB.
+func NewThroughFile(backing ReadWriteSeeker, blockSize int) (*ThroughFile, error) {
+	if blockSize <= 0 {
+		blockSize = defaultBlockSize
+	}
+	size, err := backing.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	return &ThroughFile{backing: backing, blockSize: int64(blockSize), blocks: make(map[int64][]byte), size: size}, nil
+}
+
+// Read returns data from at most one block, so it's often a partial read; use io.ReadFull
+// or io.ReadAll for more.
+func (f *ThroughFile) Read(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return 0, ErrFileClosed
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if f.offset >= f.size {
+		return 0, io.EOF
+	}
+
+	index := f.offset / f.blockSize
+	block, err := f.block(index)
+	if err != nil {
+		return 0, err
+	}
+	within := f.offset - index*f.blockSize
+	if within >= int64(len(block)) {
+		return 0, io.EOF
+	}
+	n := copy(p, block[within:])
+	f.offset += int64(n)
+	return n, nil
+}
+
+func (f *ThroughFile) block(index int64) ([]byte, error) {
+	if b, ok := f.blocks[index]; ok {
+		f.hits++
+		return b, nil
+	}
+	f.misses++
+
+	if _, err := f.backing.Seek(index*f.blockSize, io.SeekStart); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, f.blockSize)
+	n, err := io.ReadFull(f.backing, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	f.blocks[index] = buf[:n]
+	return buf[:n], nil
+}
+
+func (f *ThroughFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return 0, ErrFileClosed
+	}
+
+	if _, err := f.backing.Seek(f.offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	n, err := f.backing.Write(p)
+
+	// keep cached blocks in step with what actually reached the backing file
+	start, end := f.offset, f.offset+int64(n)
+	for index := start / f.blockSize; n > 0 && index <= (end-1)/f.blockSize; index++ {
+		block, ok := f.blocks[index]
+		if !ok {
+			continue
+		}
+		blockStart := index * f.blockSize
+		from, to := max(start, blockStart), min(end, blockStart+f.blockSize)
+		if need := to - blockStart; need > int64(len(block)) {
+			block = append(block, make([]byte, need-int64(len(block)))...)
+		}
+		copy(block[from-blockStart:to-blockStart], p[from-start:to-start])
+		f.blocks[index] = block
+	}
+
+	// a write past the end leaves a zero-filled gap; cached blocks in it are now wrong
+	if start > f.size {
+		for index := f.size / f.blockSize; index < start/f.blockSize+1; index++ {
+			if index*f.blockSize < start {
+				delete(f.blocks, index)
+			}
+		}
+	}
+
+	f.offset = end
+	f.size = max(f.size, end)
+	return n, err
+}
+
+func (f *ThroughFile) Seek(offset int64, whence int) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return 0, ErrFileClosed
+	}
+	pos, err := seekOffset(offset, whence, f.offset, f.size)
+	f.offset = pos
+	return pos, err
+}
+
+// CacheStats returns how many block lookups were served from the cache and how many went
+// to the backing file.
+func (f *ThroughFile) CacheStats() (hits, misses int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.hits, f.misses
+}
+
+// Close drops the cache and closes the backing file if it is a Closer.
+func (f *ThroughFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return ErrFileClosed
+	}
+	f.closed = true
+	f.blocks = nil
+	if c, ok := f.backing.(Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+func ReadWriterTest() {
+	dir, err := os.MkdirTemp("", "readwriters")
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	osFile, err := OpenOSFile(filepath.Join(dir, "data.txt"))
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	backing, err := OpenOSFile(filepath.Join(dir, "cached.txt"))
+	if err != nil {
+		fmt.Println("Error:", err)
+		osFile.Close()
+		return
+	}
+	through, err := NewThroughFile(backing, 8)
+	if err != nil {
+		fmt.Println("Error:", err)
+		osFile.Close()
+		backing.Close()
+		return
+	}
+
+	data := []byte("Hello, Interface Composition!")
+	for _, rw := range []ReadWriteSeekCloser{NewMemFile("mem.txt", nil), osFile, through} {
+		out, err := Process(data, rw)
+		fmt.Printf("%-14T Process read back %q err=%v\n", rw, out, err)
+	}
+
+	// Partial reads: a small buffer takes several calls, then io.EOF
+	mem := NewMemFile("greeting.txt", []byte("Hello, Gopher"))
+	buf := make([]byte, 5)
+	for {
+		n, err := mem.Read(buf)
+		fmt.Printf("read %d %q err=%v\n", n, buf[:n], err)
+		if err == io.EOF {
+			break
+		}
+	}
+
+	// Seeking past the end and writing leaves a zero-filled gap
+	mem.Seek(2, io.SeekEnd)
+	mem.Write([]byte("!"))
+	fmt.Printf("after writing past the end: %q\n", mem.Bytes())
+
+	// Reading through the cache a second time doesn't touch the backing file
+	through.Seek(0, io.SeekStart)
+	io.ReadAll(through)
+	through.Seek(0, io.SeekStart)
+	all, _ := io.ReadAll(through)
+	hits, misses := through.CacheStats()
+	fmt.Printf("through cache: %q hits=%d misses=%d\n", all, hits, misses)
+
+	// Writes go straight to the backing file
+	through.Seek(7, io.SeekStart)
+	through.Write([]byte("INTERFACE"))
+	onDisk, _ := os.ReadFile(filepath.Join(dir, "cached.txt"))
+	through.Seek(0, io.SeekStart)
+	cached, _ := io.ReadAll(through)
+	fmt.Printf("on disk: %q, via cache: %q\n", onDisk, cached)
+
+	for _, c := range []Closer{mem, osFile, through} {
+		c.Close()
+	}
+	_, err = mem.Read(buf)
+	fmt.Println("read after close:", err)
+	_, err = osFile.Write(data)
+	fmt.Println("write after close:", errors.Is(err, os.ErrClosed))
+}
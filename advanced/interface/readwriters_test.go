@@ -0,0 +1,206 @@
+package interface_example
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// files returns one of each ReadWriteSeekCloser, empty; the ThroughFile uses 4-byte blocks
+// over a file on disk so most operations cross a block boundary.
+func files(t *testing.T) map[string]ReadWriteSeekCloser {
+	t.Helper()
+	dir := t.TempDir()
+
+	osFile, err := OpenOSFile(filepath.Join(dir, "os.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	backing, err := OpenOSFile(filepath.Join(dir, "backing.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	through, err := NewThroughFile(backing, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fs := map[string]ReadWriteSeekCloser{"MemFile": NewMemFile("mem.txt", nil), "OSFile": osFile, "ThroughFile": through}
+	t.Cleanup(func() {
+		for _, f := range fs {
+			f.Close()
+		}
+	})
+	return fs
+}
+
+func TestProcess(t *testing.T) {
+	data := []byte("Hello, Interface Composition!")
+	for name, f := range files(t) {
+		t.Run(name, func(t *testing.T) {
+			out, err := Process(data, f)
+			if err != nil || !bytes.Equal(out, data) {
+				t.Fatalf("Process = %q, %v; want %q", out, err, data)
+			}
+		})
+	}
+}
+
+func TestPartialReadsAndEOF(t *testing.T) {
+	const content = "Hello, Gopher"
+	for name, f := range files(t) {
+		t.Run(name, func(t *testing.T) {
+			if _, err := f.Write([]byte(content)); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := f.Seek(0, io.SeekStart); err != nil {
+				t.Fatal(err)
+			}
+
+			var got []byte
+			buf := make([]byte, 5)
+			for reads := 0; ; reads++ {
+				if reads > len(content) {
+					t.Fatal("Read never returned io.EOF")
+				}
+				n, err := f.Read(buf)
+				if n > len(buf) {
+					t.Fatalf("Read returned %d bytes into a %d-byte buffer", n, len(buf))
+				}
+				got = append(got, buf[:n]...)
+				if err == io.EOF {
+					if n != 0 {
+						t.Errorf("Read returned %d bytes with io.EOF, want 0", n)
+					}
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if string(got) != content {
+				t.Errorf("read %q, want %q", got, content)
+			}
+
+			// reading at the end keeps returning io.EOF
+			if n, err := f.Read(buf); n != 0 || err != io.EOF {
+				t.Errorf("Read at end = %d, %v; want 0, io.EOF", n, err)
+			}
+		})
+	}
+}
+
+func TestSeekPastEndLeavesZeroGap(t *testing.T) {
+	for name, f := range files(t) {
+		t.Run(name, func(t *testing.T) {
+			f.Write([]byte("abc"))
+			if pos, err := f.Seek(3, io.SeekEnd); err != nil || pos != 6 {
+				t.Fatalf("Seek past end = %d, %v; want 6, nil", pos, err)
+			}
+			// nothing to read until something is written there
+			if n, err := f.Read(make([]byte, 4)); n != 0 || err != io.EOF {
+				t.Errorf("Read past end = %d, %v; want 0, io.EOF", n, err)
+			}
+			f.Write([]byte("!"))
+
+			f.Seek(0, io.SeekStart)
+			got, err := io.ReadAll(f)
+			if want := []byte("abc\x00\x00\x00!"); err != nil || !bytes.Equal(got, want) {
+				t.Errorf("content = %q, %v; want %q", got, err, want)
+			}
+		})
+	}
+}
+
+func TestSeekErrors(t *testing.T) {
+	for name, f := range files(t) {
+		t.Run(name, func(t *testing.T) {
+			if _, err := f.Seek(-1, io.SeekStart); err == nil {
+				t.Error("Seek to a negative offset succeeded")
+			}
+		})
+	}
+}
+
+func TestClosedFile(t *testing.T) {
+	mem := NewMemFile("mem.txt", []byte("data"))
+	mem.Close()
+	if _, err := mem.Read(make([]byte, 1)); !errors.Is(err, ErrFileClosed) {
+		t.Errorf("Read after Close = %v, want ErrFileClosed", err)
+	}
+	if err := mem.Close(); !errors.Is(err, ErrFileClosed) {
+		t.Errorf("second Close = %v, want ErrFileClosed", err)
+	}
+	if got := mem.Bytes(); string(got) != "data" {
+		t.Errorf("Bytes after Close = %q, want %q", got, "data")
+	}
+}
+
+func TestThroughFileCacheCoherence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "backing.txt")
+	if err := os.WriteFile(path, []byte("0123456789"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	backing, err := OpenOSFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	through, err := NewThroughFile(backing, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer through.Close()
+
+	readAll := func() string {
+		t.Helper()
+		if _, err := through.Seek(0, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(through)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+	check := func(step string) {
+		t.Helper()
+		onDisk, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cached := readAll(); cached != string(onDisk) {
+			t.Errorf("%s: via cache %q, on disk %q", step, cached, onDisk)
+		}
+	}
+
+	readAll() // fill every block
+	if _, misses := through.CacheStats(); misses != 3 {
+		t.Fatalf("misses after the first read = %d, want 3", misses)
+	}
+	readAll()
+	if hits, misses := through.CacheStats(); hits != 3 || misses != 3 {
+		t.Errorf("after a second read hits=%d misses=%d, want 3 and 3", hits, misses)
+	}
+
+	// a write across cached blocks updates them in place
+	through.Seek(2, io.SeekStart)
+	through.Write([]byte("abcdef"))
+	check("write across blocks")
+
+	// a write that grows the short last block
+	through.Seek(0, io.SeekEnd)
+	through.Write([]byte("XY"))
+	check("append")
+
+	// a write past the end leaves a gap the cached last block didn't have
+	readAll()
+	through.Seek(5, io.SeekEnd)
+	through.Write([]byte("Z"))
+	check("write past end")
+	if got := readAll(); got != "01abcdef89XY\x00\x00\x00\x00\x00Z" {
+		t.Errorf("content = %q", got)
+	}
+}
@@ -0,0 +1,173 @@
+package interface_example
+
+// JSON can't decode into an interface: given a Shape field, encoding/json has no idea which
+// concrete type to build. The usual fix is a tagged encoding, where every shape is wrapped
+// with its type name:
+//
+// {"type":"circle","shape":{"center":{"x":3,"y":3},"radius":2}}
+//
+// and a registry maps the name back to a Go type. Shapes from other packages can join in
+// by calling RegisterShape.
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+)
+
+var ErrUnknownShapeType = errors.New("unknown shape type")
+
+type shapeEntry struct {
+	name   string
+	decode func(data json.RawMessage) (Shape, error)
+}
+
+var (
+	registryMu   sync.RWMutex
+	shapesByName = map[string]shapeEntry{}
+	shapesByType = map[reflect.Type]shapeEntry{}
+)
+
+// RegisterShape makes T encodable under name. It panics on a duplicate name or type,
+// like http.Handle does, since that's a programming error.
+func RegisterShape[T Shape](name string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	typ := reflect.TypeFor[T]()
+	if _, dup := shapesByName[name]; dup {
+		panic(fmt.Sprintf("shape type %q registered twice", name))
+	}
+	if _, dup := shapesByType[typ]; dup {
+		panic(fmt.Sprintf("shape %v registered twice", typ))
+	}
+
+	entry := shapeEntry{
+		name: name,
+		decode: func(data json.RawMessage) (Shape, error) {
+			var v T
+			if err := json.Unmarshal(data, &v); err != nil {
+				return nil, err
+			}
+			return v, nil
+		},
+	}
+	shapesByName[name] = entry
+	shapesByType[typ] = entry
+}
+
+func init() {
+	RegisterShape[Rectangle]("rectangle")
+	RegisterShape[Circle]("circle")
+	RegisterShape[Triangle]("triangle")
+	RegisterShape[RegularPolygon]("regular_polygon")
+	RegisterShape[Ellipse]("ellipse")
+	RegisterShape[Polygon]("polygon")
+}
+
+type taggedShape struct {
+	Type  string          `json:"type"`
+	Shape json.RawMessage `json:"shape"`
+}
+
+func MarshalShape(s Shape) ([]byte, error) {
+	registryMu.RLock()
+	entry, ok := shapesByType[reflect.TypeOf(s)]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %T", ErrUnknownShapeType, s)
+	}
+
+	body, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(taggedShape{Type: entry.name, Shape: body})
+}
+
+// UnmarshalShape decodes a tagged shape and validates it, so a file can't smuggle in
+// a circle with a negative radius.
+func UnmarshalShape(data []byte) (Shape, error) {
+	var tagged taggedShape
+	if err := json.Unmarshal(data, &tagged); err != nil {
+		return nil, err
+	}
+
+	registryMu.RLock()
+	entry, ok := shapesByName[tagged.Type]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownShapeType, tagged.Type)
+	}
+	if len(tagged.Shape) == 0 {
+		return nil, fmt.Errorf("%s: missing \"shape\"", tagged.Type)
+	}
+
+	s, err := entry.decode(tagged.Shape)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", tagged.Type, err)
+	}
+	if err := Validate(s); err != nil {
+		return nil, fmt.Errorf("%s: %w", tagged.Type, err)
+	}
+	return s, nil
+}
+
+// ShapeList is a []Shape that round-trips through JSON; use it as a field type
+// wherever a struct holds shapes.
+type ShapeList []Shape
+
+func (l ShapeList) MarshalJSON() ([]byte, error) {
+	out := make([]json.RawMessage, len(l))
+	for i, s := range l {
+		data, err := MarshalShape(s)
+		if err != nil {
+			return nil, fmt.Errorf("shape %d: %w", i, err)
+		}
+		out[i] = data
+	}
+	return json.Marshal(out)
+}
+
+func (l *ShapeList) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	shapes := make(ShapeList, len(raw))
+	for i, item := range raw {
+		s, err := UnmarshalShape(item)
+		if err != nil {
+			return fmt.Errorf("shape %d: %w", i, err)
+		}
+		shapes[i] = s
+	}
+	*l = shapes
+	return nil
+}
+
+// SaveShapes writes shapes to path as an indented JSON array.
+func SaveShapes(path string, shapes []Shape) error {
+	data, err := json.MarshalIndent(ShapeList(shapes), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+func LoadShapes(path string) ([]Shape, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var shapes ShapeList
+	if err := json.Unmarshal(data, &shapes); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return shapes, nil
+}
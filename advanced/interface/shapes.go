@@ -0,0 +1,441 @@
+package interface_example
+
+// More shapes for the Shape interface, and the optional Bounded, Container,
+// Transformer and Validator methods for every shape (see geometry.go).
+// Constructors validate their input; struct literals are allowed too and can be
+// checked later with Validate.
+
+import (
+	"fmt"
+	"math"
+)
+
+// Rectangle
+
+func NewRectangle(origin Point, width, height float64) (Rectangle, error) {
+	r := Rectangle{Origin: origin, Width: width, Height: height}
+	return r, r.Validate()
+}
+
+func (r Rectangle) Validate() error {
+	if err := positive("rectangle width", r.Width); err != nil {
+		return err
+	}
+	return positive("rectangle height", r.Height)
+}
+
+func (r Rectangle) center() Point {
+	return r.Origin.Add(r.Width/2, r.Height/2)
+}
+
+func (r Rectangle) vertices() []Point {
+	o := r.Origin
+	return []Point{o, o.Add(r.Width, 0), o.Add(r.Width, r.Height), o.Add(0, r.Height)}
+}
+
+func (r Rectangle) BoundingBox() BBox {
+	return BBox{Min: r.Origin, Max: r.Origin.Add(r.Width, r.Height)}
+}
+
+func (r Rectangle) Contains(p Point) bool {
+	return r.BoundingBox().ContainsPoint(p)
+}
+
+func (r Rectangle) Translate(dx, dy float64) Shape {
+	r.Origin = r.Origin.Add(dx, dy)
+	return r
+}
+
+func (r Rectangle) Scale(factor float64) (Shape, error) {
+	if err := positive("scale factor", factor); err != nil {
+		return nil, err
+	}
+	c := r.center()
+	r.Width, r.Height = r.Width*factor, r.Height*factor
+	r.Origin = c.Add(-r.Width/2, -r.Height/2)
+	return r, nil
+}
+
+// Rotate keeps a Rectangle only for multiples of a half turn; any other angle makes it a Polygon.
+func (r Rectangle) Rotate(radians float64) Shape {
+	if math.Mod(radians, math.Pi) == 0 {
+		return r
+	}
+	return Polygon{Vertices: r.vertices()}.Rotate(radians)
+}
+
+// Circle
+
+func NewCircle(center Point, radius float64) (Circle, error) {
+	c := Circle{Center: center, Radius: radius}
+	return c, c.Validate()
+}
+
+func (c Circle) Validate() error {
+	return positive("circle radius", c.Radius)
+}
+
+func (c Circle) BoundingBox() BBox {
+	return BBox{Min: c.Center.Add(-c.Radius, -c.Radius), Max: c.Center.Add(c.Radius, c.Radius)}
+}
+
+func (c Circle) Contains(p Point) bool {
+	return c.Center.distance(p) <= c.Radius
+}
+
+func (c Circle) Translate(dx, dy float64) Shape {
+	c.Center = c.Center.Add(dx, dy)
+	return c
+}
+
+func (c Circle) Scale(factor float64) (Shape, error) {
+	if err := positive("scale factor", factor); err != nil {
+		return nil, err
+	}
+	c.Radius *= factor
+	return c, nil
+}
+
+func (c Circle) Rotate(radians float64) Shape {
+	return c
+}
+
+// Triangle
+
+type Triangle struct {
+	A Point `json:"a"`
+	B Point `json:"b"`
+	C Point `json:"c"`
+}
+
+func NewTriangle(a, b, c Point) (Triangle, error) {
+	t := Triangle{A: a, B: b, C: c}
+	return t, t.Validate()
+}
+
+func (t Triangle) Validate() error {
+	if cross(t.A, t.B, t.C) == 0 {
+		return invalid("triangle vertices are collinear")
+	}
+	return nil
+}
+
+func (t Triangle) Area() float64 {
+	return math.Abs(cross(t.A, t.B, t.C)) / 2
+}
+
+func (t Triangle) Perimeter() float64 {
+	return pathLength([]Point{t.A, t.B, t.C})
+}
+
+func (t Triangle) centroid() Point {
+	return Point{(t.A.X + t.B.X + t.C.X) / 3, (t.A.Y + t.B.Y + t.C.Y) / 3}
+}
+
+func (t Triangle) BoundingBox() BBox {
+	return bboxOf([]Point{t.A, t.B, t.C})
+}
+
+func (t Triangle) Contains(p Point) bool {
+	return pointInPolygon(p, []Point{t.A, t.B, t.C})
+}
+
+func (t Triangle) transform(fn func(Point) Point) Shape {
+	return Triangle{A: fn(t.A), B: fn(t.B), C: fn(t.C)}
+}
+
+func (t Triangle) Translate(dx, dy float64) Shape {
+	return t.transform(func(p Point) Point { return p.Add(dx, dy) })
+}
+
+func (t Triangle) Scale(factor float64) (Shape, error) {
+	if err := positive("scale factor", factor); err != nil {
+		return nil, err
+	}
+	c := t.centroid()
+	return t.transform(func(p Point) Point { return p.scaleAbout(c, factor) }), nil
+}
+
+func (t Triangle) Rotate(radians float64) Shape {
+	c := t.centroid()
+	return t.transform(func(p Point) Point { return p.rotateAbout(c, radians) })
+}
+
+// RegularPolygon has Sides equal sides, its vertices on a circle of Radius around Center.
+// Rotation (radians) is the angle of the first vertex.
+type RegularPolygon struct {
+	Center   Point   `json:"center"`
+	Sides    int     `json:"sides"`
+	Radius   float64 `json:"radius"`
+	Rotation float64 `json:"rotation,omitempty"`
+}
+
+func NewRegularPolygon(center Point, sides int, radius float64) (RegularPolygon, error) {
+	rp := RegularPolygon{Center: center, Sides: sides, Radius: radius}
+	return rp, rp.Validate()
+}
+
+func (rp RegularPolygon) Validate() error {
+	if rp.Sides < 3 {
+		return invalid("regular polygon needs at least 3 sides, got %d", rp.Sides)
+	}
+	return positive("regular polygon radius", rp.Radius)
+}
+
+func (rp RegularPolygon) Area() float64 {
+	n := float64(rp.Sides)
+	return n * rp.Radius * rp.Radius * math.Sin(2*math.Pi/n) / 2
+}
+
+func (rp RegularPolygon) Perimeter() float64 {
+	n := float64(rp.Sides)
+	return 2 * n * rp.Radius * math.Sin(math.Pi/n)
+}
+
+func (rp RegularPolygon) vertices() []Point {
+	points := make([]Point, max(rp.Sides, 0))
+	for k := range points {
+		angle := rp.Rotation + 2*math.Pi*float64(k)/float64(rp.Sides)
+		points[k] = rp.Center.Add(rp.Radius*math.Cos(angle), rp.Radius*math.Sin(angle))
+	}
+	return points
+}
+
+func (rp RegularPolygon) BoundingBox() BBox {
+	return bboxOf(rp.vertices())
+}
+
+func (rp RegularPolygon) Contains(p Point) bool {
+	return pointInPolygon(p, rp.vertices())
+}
+
+func (rp RegularPolygon) Translate(dx, dy float64) Shape {
+	rp.Center = rp.Center.Add(dx, dy)
+	return rp
+}
+
+func (rp RegularPolygon) Scale(factor float64) (Shape, error) {
+	if err := positive("scale factor", factor); err != nil {
+		return nil, err
+	}
+	rp.Radius *= factor
+	return rp, nil
+}
+
+func (rp RegularPolygon) Rotate(radians float64) Shape {
+	rp.Rotation += radians
+	return rp
+}
+
+// Ellipse has semi-axes RX and RY, rotated by Rotation radians around Center.
+type Ellipse struct {
+	Center   Point   `json:"center"`
+	RX       float64 `json:"rx"`
+	RY       float64 `json:"ry"`
+	Rotation float64 `json:"rotation,omitempty"`
+}
+
+func NewEllipse(center Point, rx, ry float64) (Ellipse, error) {
+	e := Ellipse{Center: center, RX: rx, RY: ry}
+	return e, e.Validate()
+}
+
+func (e Ellipse) Validate() error {
+	if err := positive("ellipse rx", e.RX); err != nil {
+		return err
+	}
+	return positive("ellipse ry", e.RY)
+}
+
+func (e Ellipse) Area() float64 {
+	return math.Pi * e.RX * e.RY
+}
+
+// Perimeter uses Ramanujan's second approximation; there's no closed form.
+func (e Ellipse) Perimeter() float64 {
+	a, b := e.RX, e.RY
+	h := (a - b) * (a - b) / ((a + b) * (a + b))
+	return math.Pi * (a + b) * (1 + 3*h/(10+math.Sqrt(4-3*h)))
+}
+
+func (e Ellipse) BoundingBox() BBox {
+	sin, cos := math.Sincos(e.Rotation)
+	hw := math.Sqrt(e.RX*e.RX*cos*cos + e.RY*e.RY*sin*sin)
+	hh := math.Sqrt(e.RX*e.RX*sin*sin + e.RY*e.RY*cos*cos)
+	return BBox{Min: e.Center.Add(-hw, -hh), Max: e.Center.Add(hw, hh)}
+}
+
+func (e Ellipse) Contains(p Point) bool {
+	q := p.rotateAbout(e.Center, -e.Rotation) // into the ellipse's own axes
+	dx, dy := (q.X-e.Center.X)/e.RX, (q.Y-e.Center.Y)/e.RY
+	return dx*dx+dy*dy <= 1
+}
+
+func (e Ellipse) approximate(segments int) []Point {
+	points := make([]Point, segments)
+	for k := range points {
+		t := 2 * math.Pi * float64(k) / float64(segments)
+		p := e.Center.Add(e.RX*math.Cos(t), e.RY*math.Sin(t))
+		points[k] = p.rotateAbout(e.Center, e.Rotation)
+	}
+	return points
+}
+
+func (e Ellipse) Translate(dx, dy float64) Shape {
+	e.Center = e.Center.Add(dx, dy)
+	return e
+}
+
+func (e Ellipse) Scale(factor float64) (Shape, error) {
+	if err := positive("scale factor", factor); err != nil {
+		return nil, err
+	}
+	e.RX, e.RY = e.RX*factor, e.RY*factor
+	return e, nil
+}
+
+func (e Ellipse) Rotate(radians float64) Shape {
+	e.Rotation += radians
+	return e
+}
+
+// Polygon is an arbitrary simple polygon: edges don't cross, vertices in order (either direction).
+type Polygon struct {
+	Vertices []Point `json:"vertices"`
+}
+
+func NewPolygon(vertices ...Point) (Polygon, error) {
+	p := Polygon{Vertices: vertices}
+	return p, p.Validate()
+}
+
+func (p Polygon) Validate() error {
+	n := len(p.Vertices)
+	if n < 3 {
+		return invalid("polygon needs at least 3 vertices, got %d", n)
+	}
+	if shoelace(p.Vertices) == 0 {
+		return invalid("polygon has zero area")
+	}
+	// every pair of non-adjacent edges must be disjoint
+	for i := range n {
+		for j := i + 1; j < n; j++ {
+			if j == i+1 || (i == 0 && j == n-1) {
+				continue
+			}
+			if segmentsIntersect(p.Vertices[i], p.Vertices[(i+1)%n], p.Vertices[j], p.Vertices[(j+1)%n]) {
+				return invalid("polygon edges %d and %d cross", i, j)
+			}
+		}
+	}
+	return nil
+}
+
+// Area uses the shoelace formula.
+func (p Polygon) Area() float64 {
+	return math.Abs(shoelace(p.Vertices))
+}
+
+func (p Polygon) Perimeter() float64 {
+	return pathLength(p.Vertices)
+}
+
+// centroid is the area-weighted center. A degenerate polygon (which Validate rejects)
+// has no area to weigh by, so it falls back to the mean of its vertices.
+func (p Polygon) centroid() Point {
+	a := shoelace(p.Vertices)
+	if a == 0 {
+		var mean Point
+		for _, v := range p.Vertices {
+			mean = mean.Add(v.X/float64(len(p.Vertices)), v.Y/float64(len(p.Vertices)))
+		}
+		return mean
+	}
+	var cx, cy float64
+	for i := range p.Vertices {
+		v, w := p.Vertices[i], p.Vertices[(i+1)%len(p.Vertices)]
+		f := v.X*w.Y - w.X*v.Y
+		cx += (v.X + w.X) * f
+		cy += (v.Y + w.Y) * f
+	}
+	return Point{cx / (6 * a), cy / (6 * a)}
+}
+
+func (p Polygon) BoundingBox() BBox {
+	return bboxOf(p.Vertices)
+}
+
+func (p Polygon) Contains(pt Point) bool {
+	return pointInPolygon(pt, p.Vertices)
+}
+
+func (p Polygon) Translate(dx, dy float64) Shape {
+	return Polygon{Vertices: transformPoints(p.Vertices, func(v Point) Point { return v.Add(dx, dy) })}
+}
+
+func (p Polygon) Scale(factor float64) (Shape, error) {
+	if err := positive("scale factor", factor); err != nil {
+		return nil, err
+	}
+	c := p.centroid()
+	return Polygon{Vertices: transformPoints(p.Vertices, func(v Point) Point { return v.scaleAbout(c, factor) })}, nil
+}
+
+func (p Polygon) Rotate(radians float64) Shape {
+	c := p.centroid()
+	return Polygon{Vertices: transformPoints(p.Vertices, func(v Point) Point { return v.rotateAbout(c, radians) })}
+}
+
+func GeometryTest() {
+	tri, _ := NewTriangle(Point{0, 0}, Point{4, 0}, Point{0, 3})
+	hexagon, _ := NewRegularPolygon(Point{10, 10}, 6, 2)
+	ellipse, _ := NewEllipse(Point{-5, 0}, 3, 1)
+	lShape, _ := NewPolygon(Point{0, 0}, Point{4, 0}, Point{4, 1}, Point{1, 1}, Point{1, 4}, Point{0, 4})
+
+	shapes := []Shape{
+		Rectangle{Width: 10, Height: 5},
+		Circle{Center: Point{3, 3}, Radius: 2},
+		tri, hexagon, ellipse, lShape,
+	}
+	for _, s := range shapes {
+		fmt.Printf("%-15T area=%7.2f perimeter=%6.2f", s, s.Area(), s.Perimeter())
+		if b, ok := s.(Bounded); ok {
+			box := b.BoundingBox()
+			fmt.Printf(" bbox=(%.1f,%.1f)-(%.1f,%.1f)", box.Min.X, box.Min.Y, box.Max.X, box.Max.Y)
+		}
+		if c, ok := s.(Container); ok {
+			fmt.Printf(" contains(0.5,2)=%v", c.Contains(Point{0.5, 2}))
+		}
+		fmt.Println()
+	}
+
+	// Transformations return new shapes; a rotated rectangle becomes a polygon
+	rotated := Rectangle{Width: 4, Height: 2}.Rotate(math.Pi / 4)
+	fmt.Printf("rotated rectangle: %T with area %.2f\n", rotated, rotated.Area())
+	moved, _ := tri.Translate(100, 0).(Transformer).Scale(2)
+	fmt.Printf("moved and scaled triangle: %+v area=%.2f\n", moved, moved.Area())
+	_, err := tri.Scale(0)
+	fmt.Println("scale by 0:", err)
+	fmt.Println("empty polygon bbox:", Polygon{}.BoundingBox(), Validate(Polygon{}))
+
+	// Intersection tests
+	pairs := [][2]Shape{
+		{tri, lShape},
+		{hexagon, lShape},
+		{Circle{Center: Point{6, 0}, Radius: 2}, tri},
+		{ellipse, Circle{Center: Point{-1.5, 0}, Radius: 0.6}},
+	}
+	for _, pair := range pairs {
+		hit, err := Intersects(pair[0], pair[1])
+		fmt.Printf("%T intersects %T: %v %v\n", pair[0], pair[1], hit, err)
+	}
+
+	// Validation rejects negative dimensions and degenerate shapes
+	_, err = NewRectangle(Point{}, -1, 2)
+	fmt.Println(err)
+	_, err = NewTriangle(Point{0, 0}, Point{1, 1}, Point{2, 2})
+	fmt.Println(err)
+	_, err = NewPolygon(Point{0, 0}, Point{4, 4}, Point{4, 0}, Point{0, 2}) // self-crossing bow tie
+	fmt.Println(err)
+	fmt.Println(Validate(Circle{Radius: -3}))
+}
@@ -0,0 +1,470 @@
+package interface_example
+
+// An R-tree indexes shapes by their bounding boxes. Every node holds up to maxEntries
+// boxes; an internal node's box covers its whole subtree, so a query only descends into
+// subtrees whose box overlaps what it's looking for, and skips the rest. With tens of
+// thousands of shapes that turns a full scan into a handful of node visits.
+//
+// Boxes only narrow the search down; the final answer uses the shapes' own Contains and
+// Intersects, so a point in the corner of a circle's box is not reported as inside it.
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"sync"
+)
+
+const defaultMaxEntries = 16
+
+// ShapeID identifies a shape in a SpatialIndex; shapes themselves aren't always
+// comparable (a Polygon holds a slice), so Delete goes by ID.
+type ShapeID int
+
+type IndexedShape struct {
+	ID    ShapeID
+	Shape Shape
+}
+
+var ErrNotBounded = errors.New("shape has no bounding box")
+
+type rentry struct {
+	box   BBox
+	child *rnode  // internal nodes
+	id    ShapeID // leaves
+}
+
+type rnode struct {
+	leaf    bool
+	entries []rentry
+}
+
+func (n *rnode) bbox() BBox {
+	box := n.entries[0].box
+	for _, e := range n.entries[1:] {
+		box = box.Union(e.box)
+	}
+	return box
+}
+
+type SpatialIndex struct {
+	mu         sync.RWMutex
+	root       *rnode
+	shapes     map[ShapeID]IndexedShape
+	boxes      map[ShapeID]BBox
+	nextID     ShapeID
+	maxEntries int
+	minEntries int
+}
+
+// NewSpatialIndex returns an empty index; maxEntries is the node size (0 means 16).
+func NewSpatialIndex(maxEntries int) *SpatialIndex {
+	if maxEntries < 4 {
+		maxEntries = defaultMaxEntries
+	}
+	return &SpatialIndex{
+		root:       &rnode{leaf: true},
+		shapes:     make(map[ShapeID]IndexedShape),
+		boxes:      make(map[ShapeID]BBox),
+		nextID:     1,
+		maxEntries: maxEntries,
+		minEntries: maxEntries * 2 / 5,
+	}
+}
+
+func (t *SpatialIndex) Len() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return len(t.shapes)
+}
+
+func (t *SpatialIndex) Get(id ShapeID) (Shape, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	s, ok := t.shapes[id]
+	return s.Shape, ok
+}
+
+func (t *SpatialIndex) Insert(s Shape) (ShapeID, error) {
+	b, ok := s.(Bounded)
+	if !ok {
+		return 0, fmt.Errorf("%w: %T", ErrNotBounded, s)
+	}
+	if err := Validate(s); err != nil {
+		return 0, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	id := t.nextID
+	t.nextID++
+	box := b.BoundingBox()
+	t.shapes[id] = IndexedShape{ID: id, Shape: s}
+	t.boxes[id] = box
+	t.insertEntry(rentry{box: box, id: id})
+	return id, nil
+}
+
+// Delete removes a shape and reports whether it was in the index.
+func (t *SpatialIndex) Delete(id ShapeID) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	box, ok := t.boxes[id]
+	if !ok {
+		return false
+	}
+	delete(t.shapes, id)
+	delete(t.boxes, id)
+
+	var orphans []rentry
+	t.remove(t.root, id, box, &orphans)
+
+	// shrink the tree when the root is left with a single child
+	for !t.root.leaf && len(t.root.entries) == 1 {
+		t.root = t.root.entries[0].child
+	}
+	if !t.root.leaf && len(t.root.entries) == 0 {
+		t.root = &rnode{leaf: true}
+	}
+
+	for _, e := range orphans {
+		t.insertEntry(e)
+	}
+	return true
+}
+
+// Containing returns the shapes that contain p, ordered by ID.
+func (t *SpatialIndex) Containing(p Point) []IndexedShape {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var out []IndexedShape
+	t.search(t.root, BBox{Min: p, Max: p}, func(id ShapeID) {
+		s := t.shapes[id]
+		if c, ok := s.Shape.(Container); ok && !c.Contains(p) {
+			return
+		}
+		out = append(out, s)
+	})
+	return sortByID(out)
+}
+
+// Overlapping returns the shapes that overlap or touch box, ordered by ID. The box may be
+// a single point or a horizontal or vertical line. Shapes that support neither Intersects
+// nor an outline are matched on their bounding box alone.
+func (t *SpatialIndex) Overlapping(box BBox) []IndexedShape {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var out []IndexedShape
+	t.search(t.root, box, func(id ShapeID) {
+		s := t.shapes[id]
+		if hit, exact := overlapsBox(s.Shape, box); exact && !hit {
+			return
+		}
+		out = append(out, s)
+	})
+	return sortByID(out)
+}
+
+// overlapsBox tests s against box exactly; exact is false when the shape can't be tested.
+// A box without area isn't a valid Rectangle, so a point is tested with Contains and a line
+// against the shape's outline.
+func overlapsBox(s Shape, box BBox) (hit, exact bool) {
+	switch {
+	case box.Width() > 0 && box.Height() > 0:
+		hit, err := Intersects(s, Rectangle{Origin: box.Min, Width: box.Width(), Height: box.Height()})
+		return hit, err == nil
+	case box.Width() == 0 && box.Height() == 0:
+		c, ok := s.(Container)
+		if !ok {
+			return false, false
+		}
+		return c.Contains(box.Min), true
+	}
+
+	a, b := box.Min, box.Max
+	if c, ok := s.(Circle); ok {
+		return segmentDistance(c.Center, a, b) <= c.Radius, true
+	}
+	poly, ok := outline(s)
+	if !ok {
+		return false, false
+	}
+	if pointInPolygon(a, poly) {
+		return true, true
+	}
+	for i := range poly {
+		if segmentsIntersect(a, b, poly[i], poly[(i+1)%len(poly)]) {
+			return true, true
+		}
+	}
+	return false, true
+}
+
+func sortByID(shapes []IndexedShape) []IndexedShape {
+	slices.SortFunc(shapes, func(a, b IndexedShape) int {
+		return int(a.ID - b.ID)
+	})
+	return shapes
+}
+
+func (t *SpatialIndex) search(n *rnode, box BBox, visit func(ShapeID)) {
+	for _, e := range n.entries {
+		if !e.box.Intersects(box) {
+			continue
+		}
+		if n.leaf {
+			visit(e.id)
+		} else {
+			t.search(e.child, box, visit)
+		}
+	}
+}
+
+func (t *SpatialIndex) insertEntry(e rentry) {
+	if sibling := t.insert(t.root, e); sibling != nil {
+		// the root split: grow the tree by one level
+		old := t.root
+		t.root = &rnode{entries: []rentry{
+			{box: old.bbox(), child: old},
+			{box: sibling.bbox(), child: sibling},
+		}}
+	}
+}
+
+// insert adds a leaf entry below n and returns the new sibling if n had to split.
+func (t *SpatialIndex) insert(n *rnode, e rentry) *rnode {
+	if n.leaf {
+		n.entries = append(n.entries, e)
+	} else {
+		i := chooseSubtree(n, e.box)
+		child := n.entries[i].child
+		sibling := t.insert(child, e)
+		n.entries[i].box = child.bbox()
+		if sibling != nil {
+			n.entries = append(n.entries, rentry{box: sibling.bbox(), child: sibling})
+		}
+	}
+
+	if len(n.entries) > t.maxEntries {
+		return t.split(n)
+	}
+	return nil
+}
+
+// chooseSubtree picks the child whose box grows the least, preferring smaller boxes on ties.
+func chooseSubtree(n *rnode, box BBox) int {
+	best, bestGrowth, bestArea := 0, math.Inf(1), math.Inf(1)
+	for i, e := range n.entries {
+		area := boxArea(e.box)
+		growth := boxArea(e.box.Union(box)) - area
+		if growth < bestGrowth || growth == bestGrowth && area < bestArea {
+			best, bestGrowth, bestArea = i, growth, area
+		}
+	}
+	return best
+}
+
+// split is Guttman's quadratic split: start from the two entries that would waste the most
+// space together, then hand out the rest one at a time to the group that grows the least.
+func (t *SpatialIndex) split(n *rnode) *rnode {
+	entries := n.entries
+
+	seedA, seedB, worst := 0, 1, math.Inf(-1)
+	for i := range entries {
+		for j := i + 1; j < len(entries); j++ {
+			waste := boxArea(entries[i].box.Union(entries[j].box)) - boxArea(entries[i].box) - boxArea(entries[j].box)
+			if waste > worst {
+				seedA, seedB, worst = i, j, waste
+			}
+		}
+	}
+
+	a := []rentry{entries[seedA]}
+	b := []rentry{entries[seedB]}
+	boxA, boxB := entries[seedA].box, entries[seedB].box
+
+	rest := make([]rentry, 0, len(entries)-2)
+	for i, e := range entries {
+		if i != seedA && i != seedB {
+			rest = append(rest, e)
+		}
+	}
+
+	for len(rest) > 0 {
+		// a group that needs every remaining entry to reach the minimum gets them all
+		if len(a)+len(rest) == t.minEntries {
+			a = append(a, rest...)
+			break
+		}
+		if len(b)+len(rest) == t.minEntries {
+			b = append(b, rest...)
+			break
+		}
+
+		// pick the entry with the strongest preference for one group
+		pick, pickDiff := 0, -1.0
+		for i, e := range rest {
+			diff := math.Abs(growth(boxA, e.box) - growth(boxB, e.box))
+			if diff > pickDiff {
+				pick, pickDiff = i, diff
+			}
+		}
+		e := rest[pick]
+		rest = slices.Delete(rest, pick, pick+1)
+
+		ga, gb := growth(boxA, e.box), growth(boxB, e.box)
+		toA := ga < gb ||
+			ga == gb && boxArea(boxA) < boxArea(boxB) ||
+			ga == gb && boxArea(boxA) == boxArea(boxB) && len(a) <= len(b)
+		if toA {
+			a = append(a, e)
+			boxA = boxA.Union(e.box)
+		} else {
+			b = append(b, e)
+			boxB = boxB.Union(e.box)
+		}
+	}
+
+	n.entries = a
+	return &rnode{leaf: n.leaf, entries: b}
+}
+
+// remove deletes id below n. Nodes left with fewer than minEntries are dropped and their
+// shapes collected into orphans for reinsertion.
+func (t *SpatialIndex) remove(n *rnode, id ShapeID, box BBox, orphans *[]rentry) bool {
+	if n.leaf {
+		for i, e := range n.entries {
+			if e.id == id {
+				n.entries = slices.Delete(n.entries, i, i+1)
+				return true
+			}
+		}
+		return false
+	}
+
+	for i, e := range n.entries {
+		if !e.box.Intersects(box) || !t.remove(e.child, id, box, orphans) {
+			continue
+		}
+		if len(e.child.entries) < t.minEntries {
+			collectLeaves(e.child, orphans)
+			n.entries = slices.Delete(n.entries, i, i+1)
+		} else {
+			n.entries[i].box = e.child.bbox()
+		}
+		return true
+	}
+	return false
+}
+
+func collectLeaves(n *rnode, out *[]rentry) {
+	if n.leaf {
+		*out = append(*out, n.entries...)
+		return
+	}
+	for _, e := range n.entries {
+		collectLeaves(e.child, out)
+	}
+}
+
+func boxArea(b BBox) float64 {
+	return b.Width() * b.Height()
+}
+
+func growth(b, add BBox) float64 {
+	return boxArea(b.Union(add)) - boxArea(b)
+}
+
+// TotalArea sums the area of all shapes.
+func TotalArea(shapes []Shape) float64 {
+	total := 0.0
+	for _, s := range shapes {
+		total += s.Area()
+	}
+	return total
+}
+
+// LargestPerimeter returns the shape with the largest perimeter, or false for an empty slice.
+func LargestPerimeter(shapes []Shape) (Shape, bool) {
+	if len(shapes) == 0 {
+		return nil, false
+	}
+	largest := shapes[0]
+	for _, s := range shapes[1:] {
+		if s.Perimeter() > largest.Perimeter() {
+			largest = s
+		}
+	}
+	return largest, true
+}
+
+func SpatialIndexTest() {
+	// A fixed seed keeps the demo output stable
+	rng := rand.New(rand.NewPCG(1, 2))
+	const count = 20000
+
+	shapes := make([]Shape, 0, count)
+	for i := range count {
+		at := Point{rng.Float64() * 1000, rng.Float64() * 1000}
+		size := 1 + rng.Float64()*4
+		var s Shape
+		switch i % 3 {
+		case 0:
+			s, _ = NewRectangle(at, size, size*2)
+		case 1:
+			s, _ = NewCircle(at, size)
+		default:
+			s, _ = NewRegularPolygon(at, 3+i%5, size)
+		}
+		shapes = append(shapes, s)
+	}
+
+	index := NewSpatialIndex(0)
+	ids := make([]ShapeID, len(shapes))
+	for i, s := range shapes {
+		ids[i], _ = index.Insert(s)
+	}
+	fmt.Printf("indexed %d shapes, total area %.0f\n", index.Len(), TotalArea(shapes))
+	if s, ok := LargestPerimeter(shapes); ok {
+		fmt.Printf("largest perimeter: %T %.2f\n", s, s.Perimeter())
+	}
+
+	// Compare with a full scan
+	p := shapes[1].(Circle).Center
+	window := BBox{Min: Point{400, 400}, Max: Point{450, 450}}
+	windowRect := Rectangle{Origin: window.Min, Width: window.Width(), Height: window.Height()}
+
+	scanContaining, scanOverlapping := 0, 0
+	for _, s := range shapes {
+		if s.(Container).Contains(p) {
+			scanContaining++
+		}
+		if hit, _ := Intersects(s, windowRect); hit {
+			scanOverlapping++
+		}
+	}
+	containing := index.Containing(p)
+	overlapping := index.Overlapping(window)
+
+	fmt.Printf("containing (%.1f, %.1f): index=%d scan=%d\n", p.X, p.Y, len(containing), scanContaining)
+	fmt.Printf("overlapping %v-%v: index=%d scan=%d\n", window.Min, window.Max, len(overlapping), scanOverlapping)
+
+	// Delete every other shape; the queries only see what's left
+	kept := 0
+	for i, id := range ids {
+		if i%2 == 0 {
+			index.Delete(id)
+		} else if hit, _ := Intersects(shapes[i], windowRect); hit {
+			kept++
+		}
+	}
+	fmt.Printf("after deleting half: %d shapes, overlapping: index=%d scan=%d\n",
+		index.Len(), len(index.Overlapping(window)), kept)
+	fmt.Println("delete unknown ID:", index.Delete(ids[0]))
+}
@@ -0,0 +1,128 @@
+package interface_example
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func randomShapes(t *testing.T, rng *rand.Rand, n int) []Shape {
+	t.Helper()
+	shapes := make([]Shape, 0, n)
+	for i := range n {
+		at := Point{rng.Float64() * 100, rng.Float64() * 100}
+		size := 1 + rng.Float64()*5
+		var s Shape
+		var err error
+		switch i % 3 {
+		case 0:
+			s, err = NewRectangle(at, size, size*2)
+		case 1:
+			s, err = NewCircle(at, size)
+		default:
+			s, err = NewRegularPolygon(at, 3+i%5, size)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		shapes = append(shapes, s)
+	}
+	return shapes
+}
+
+func ids(shapes []IndexedShape) []ShapeID {
+	out := make([]ShapeID, len(shapes))
+	for i, s := range shapes {
+		out[i] = s.ID
+	}
+	return out
+}
+
+// scan is the brute-force answer the index must match.
+func scan(shapes map[ShapeID]Shape, match func(Shape) bool) []ShapeID {
+	var out []ShapeID
+	for id, s := range shapes {
+		if match(s) {
+			out = append(out, id)
+		}
+	}
+	slices.Sort(out)
+	return out
+}
+
+func TestSpatialIndexMatchesScan(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	index := NewSpatialIndex(4) // small nodes, so the tree gets a few levels
+	live := map[ShapeID]Shape{}
+	for _, s := range randomShapes(t, rng, 500) {
+		id, err := index.Insert(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		live[id] = s
+	}
+
+	check := func(t *testing.T) {
+		t.Helper()
+		for range 200 {
+			p := Point{rng.Float64() * 100, rng.Float64() * 100}
+			want := scan(live, func(s Shape) bool { return s.(Container).Contains(p) })
+			if got := ids(index.Containing(p)); !slices.Equal(got, want) {
+				t.Fatalf("Containing(%v) = %v, scan %v", p, got, want)
+			}
+
+			corner := Point{rng.Float64() * 100, rng.Float64() * 100}
+			box := BBox{Min: corner, Max: corner.Add(rng.Float64()*20+0.1, rng.Float64()*20+0.1)}
+			rect := Rectangle{Origin: box.Min, Width: box.Width(), Height: box.Height()}
+			want = scan(live, func(s Shape) bool {
+				hit, err := Intersects(s, rect)
+				return err == nil && hit
+			})
+			if got := ids(index.Overlapping(box)); !slices.Equal(got, want) {
+				t.Fatalf("Overlapping(%v) = %v, scan %v", box, got, want)
+			}
+		}
+	}
+
+	t.Run("after inserts", check)
+	for id := range live {
+		if id%3 == 0 {
+			if !index.Delete(id) {
+				t.Fatalf("Delete(%d) = false", id)
+			}
+			delete(live, id)
+		}
+	}
+	t.Run("after deletes", check)
+}
+
+func TestOverlappingDegenerateBoxes(t *testing.T) {
+	index := NewSpatialIndex(0)
+	circle, _ := NewCircle(Point{10, 10}, 5)
+	square, _ := NewRectangle(Point{30, 0}, 10, 10)
+	circleID, _ := index.Insert(circle)
+	squareID, _ := index.Insert(square)
+
+	tests := []struct {
+		name string
+		box  BBox
+		want []ShapeID
+	}{
+		// (5.5, 5.5) is inside the circle's bounding box but outside the circle
+		{"point in a bounding box corner", BBox{Min: Point{5.5, 5.5}, Max: Point{5.5, 5.5}}, nil},
+		{"point inside", BBox{Min: Point{10, 12}, Max: Point{10, 12}}, []ShapeID{circleID}},
+		{"point on an edge", BBox{Min: Point{30, 5}, Max: Point{30, 5}}, []ShapeID{squareID}},
+		{"vertical line past the circle", BBox{Min: Point{5.5, 5}, Max: Point{5.5, 6}}, nil},
+		{"vertical line through the circle", BBox{Min: Point{10, 0}, Max: Point{10, 30}}, []ShapeID{circleID}},
+		{"horizontal line through both", BBox{Min: Point{0, 10}, Max: Point{50, 10}}, []ShapeID{circleID, squareID}},
+		{"horizontal line inside the square", BBox{Min: Point{32, 5}, Max: Point{38, 5}}, []ShapeID{squareID}},
+		{"horizontal line between them", BBox{Min: Point{16, 2}, Max: Point{29, 2}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ids(index.Overlapping(tt.box)); !slices.Equal(got, tt.want) {
+				t.Errorf("Overlapping(%v) = %v, want %v", tt.box, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,592 @@
+package interface_example
+
+// Stream layers: each one wraps a Writer (or Reader) and is itself a Writer (or Reader), so
+// they stack in any order. Writing through
+//
+// StackWriters(file, Gzip(), Encrypt(key))
+//
+// compresses, then encrypts, then stores. Reading it back peels the layers off in the same
+// order as seen from the file: StackReaders(file, Decrypt(key), Gunzip()).
+//
+// Some layers buffer (gzip, encryption, base64), so a write stack must be closed to flush
+// everything. Close flushes every layer top to bottom but leaves the destination open;
+// whoever opened the file closes it.
+
+import (
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+	"time"
+)
+
+var (
+	ErrStreamAuth      = errors.New("encrypted stream failed authentication")
+	ErrStreamTruncated = errors.New("encrypted stream is truncated")
+)
+
+type WriterLayer func(next Writer) (WriteCloser, error)
+
+type ReaderLayer func(src Reader) (Reader, error)
+
+// StackWriters puts layers in front of dst; data written goes through layers[0] first.
+func StackWriters(dst Writer, layers ...WriterLayer) (WriteCloser, error) {
+	var w WriteCloser = nopWriteCloser{dst}
+	for i := len(layers) - 1; i >= 0; i-- {
+		next, err := layers[i](w)
+		if err != nil {
+			return nil, err
+		}
+		w = next
+	}
+	return w, nil
+}
+
+// StackReaders reads src through layers; layers[0] sees the raw bytes of src.
+func StackReaders(src Reader, layers ...ReaderLayer) (Reader, error) {
+	r := src
+	for _, layer := range layers {
+		next, err := layer(r)
+		if err != nil {
+			return nil, err
+		}
+		r = next
+	}
+	return r, nil
+}
+
+// nopWriteCloser stops Close from reaching the destination.
+type nopWriteCloser struct {
+	Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// layerWriter writes through w; Close runs flush and then closes next. Only the first
+// Close does anything, so flushing twice can't append a second trailer.
+type layerWriter struct {
+	w      Writer
+	flush  func() error
+	next   Writer
+	closed bool
+}
+
+func (l *layerWriter) Write(p []byte) (int, error) {
+	return l.w.Write(p)
+}
+
+func (l *layerWriter) Close() error {
+	if l.closed {
+		return nil
+	}
+	l.closed = true
+
+	var err error
+	if l.flush != nil {
+		err = l.flush()
+	}
+	if c, ok := l.next.(Closer); ok {
+		err = errors.Join(err, c.Close())
+	}
+	return err
+}
+
+func Gzip() WriterLayer {
+	return func(next Writer) (WriteCloser, error) {
+		zw := gzip.NewWriter(next)
+		return &layerWriter{w: zw, flush: zw.Close, next: next}, nil
+	}
+}
+
+func Gunzip() ReaderLayer {
+	return func(src Reader) (Reader, error) {
+		return gzip.NewReader(src)
+	}
+}
+
+func HexEncode() WriterLayer {
+	return func(next Writer) (WriteCloser, error) {
+		return &layerWriter{w: hex.NewEncoder(next), next: next}, nil
+	}
+}
+
+func HexDecode() ReaderLayer {
+	return func(src Reader) (Reader, error) {
+		return hex.NewDecoder(src), nil
+	}
+}
+
+func Base64Encode() WriterLayer {
+	return func(next Writer) (WriteCloser, error) {
+		enc := base64.NewEncoder(base64.StdEncoding, next)
+		return &layerWriter{w: enc, flush: enc.Close, next: next}, nil
+	}
+}
+
+func Base64Decode() ReaderLayer {
+	return func(src Reader) (Reader, error) {
+		return base64.NewDecoder(base64.StdEncoding, src), nil
+	}
+}
+
+// LineCounter collects what a CountLines layer has seen.
+type LineCounter struct {
+	Lines int64 // newline characters
+	Bytes int64
+}
+
+func (c *LineCounter) add(p []byte) {
+	c.Bytes += int64(len(p))
+	c.Lines += int64(strings.Count(string(p), "\n"))
+}
+
+type countingWriter struct {
+	next    Writer
+	counter *LineCounter
+}
+
+func (w countingWriter) Write(p []byte) (int, error) {
+	n, err := w.next.Write(p)
+	w.counter.add(p[:n])
+	return n, err
+}
+
+func CountLines(c *LineCounter) WriterLayer {
+	return func(next Writer) (WriteCloser, error) {
+		return &layerWriter{w: countingWriter{next, c}, next: next}, nil
+	}
+}
+
+type countingReader struct {
+	src     Reader
+	counter *LineCounter
+}
+
+func (r countingReader) Read(p []byte) (int, error) {
+	n, err := r.src.Read(p)
+	r.counter.add(p[:n])
+	return n, err
+}
+
+func CountLinesRead(c *LineCounter) ReaderLayer {
+	return func(src Reader) (Reader, error) {
+		return countingReader{src, c}, nil
+	}
+}
+
+// Checksum feeds everything written into h on its way through; read h.Sum(nil) after Close.
+func Checksum(h hash.Hash) WriterLayer {
+	return func(next Writer) (WriteCloser, error) {
+		tee := WriterFunc(func(p []byte) (int, error) {
+			n, err := next.Write(p)
+			h.Write(p[:n])
+			return n, err
+		})
+		return &layerWriter{w: tee, next: next}, nil
+	}
+}
+
+func ChecksumRead(h hash.Hash) ReaderLayer {
+	return func(src Reader) (Reader, error) {
+		return io.TeeReader(src, h), nil
+	}
+}
+
+// WriterFunc turns a function into a Writer, like http.HandlerFunc.
+type WriterFunc func(p []byte) (int, error)
+
+func (f WriterFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
+
+// limiter paces a stream at rate bytes per second, measured from the first byte. now and
+// sleep are time.Now and time.Sleep outside of tests.
+type limiter struct {
+	rate  float64
+	chunk int
+	start time.Time
+	done  int64
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+func newLimiter(bytesPerSec int, now func() time.Time, sleep func(time.Duration)) (*limiter, error) {
+	if bytesPerSec <= 0 {
+		return nil, fmt.Errorf("rate must be positive, got %d", bytesPerSec)
+	}
+	// move at most 1/20 s worth of data at once, so the pace is smooth
+	return &limiter{rate: float64(bytesPerSec), chunk: max(bytesPerSec/20, 1), now: now, sleep: sleep}, nil
+}
+
+func (l *limiter) wait(n int) {
+	if l.start.IsZero() {
+		l.start = l.now()
+	}
+	l.done += int64(n)
+	due := l.start.Add(time.Duration(float64(l.done) / l.rate * float64(time.Second)))
+	if d := due.Sub(l.now()); d > 0 {
+		l.sleep(d)
+	}
+}
+
+func RateLimit(bytesPerSec int) WriterLayer {
+	return rateLimit(bytesPerSec, time.Now, time.Sleep)
+}
+
+func rateLimit(bytesPerSec int, now func() time.Time, sleep func(time.Duration)) WriterLayer {
+	return func(next Writer) (WriteCloser, error) {
+		l, err := newLimiter(bytesPerSec, now, sleep)
+		if err != nil {
+			return nil, err
+		}
+		paced := WriterFunc(func(p []byte) (int, error) {
+			written := 0
+			for written < len(p) {
+				end := min(written+l.chunk, len(p))
+				n, err := next.Write(p[written:end])
+				written += n
+				l.wait(n)
+				if err != nil {
+					return written, err
+				}
+			}
+			return written, nil
+		})
+		return &layerWriter{w: paced, next: next}, nil
+	}
+}
+
+type pacedReader struct {
+	src Reader
+	l   *limiter
+}
+
+func (r pacedReader) Read(p []byte) (int, error) {
+	if len(p) > r.l.chunk {
+		p = p[:r.l.chunk]
+	}
+	n, err := r.src.Read(p)
+	r.l.wait(n)
+	return n, err
+}
+
+func RateLimitRead(bytesPerSec int) ReaderLayer {
+	return rateLimitRead(bytesPerSec, time.Now, time.Sleep)
+}
+
+func rateLimitRead(bytesPerSec int, now func() time.Time, sleep func(time.Duration)) ReaderLayer {
+	return func(src Reader) (Reader, error) {
+		l, err := newLimiter(bytesPerSec, now, sleep)
+		if err != nil {
+			return nil, err
+		}
+		return pacedReader{src, l}, nil
+	}
+}
+
+// AES-GCM can't encrypt an endless stream in one go, so the stream is cut into chunks,
+// each sealed on its own:
+//
+// header: 32-byte random salt
+// chunk:  1-byte flag (1 on the last chunk), 4-byte length, ciphertext+tag
+//
+// The caller's key is never used directly: every stream seals with its own subkey, derived
+// from the key and the salt with HKDF. Random nonces under one long-lived key would collide
+// after enough streams; a fresh key per stream makes a counter nonce safe. A chunk's nonce
+// is flag + chunk counter, so chunks can't be reordered, a stream without a final chunk
+// is detected as truncated, and bytes after the final chunk fail authentication.
+
+const (
+	sealChunkSize  = 64 << 10
+	streamSaltSize = 32
+	streamKeyInfo  = "go-practice stream chunk key"
+)
+
+// newStreamGCM derives the stream's subkey, the same size as key, and sets up AES-GCM with it.
+func newStreamGCM(key, salt []byte) (cipher.AEAD, error) {
+	if _, err := aes.NewCipher(key); err != nil { // 16, 24 or 32 bytes for AES-128/192/256
+		return nil, err
+	}
+	subkey, err := hkdf.Key(sha256.New, key, salt, streamKeyInfo, len(key))
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(subkey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func chunkNonce(last bool, counter uint32) []byte {
+	nonce := make([]byte, 12)
+	if last {
+		nonce[7] = 1
+	}
+	binary.BigEndian.PutUint32(nonce[8:], counter)
+	return nonce
+}
+
+type encryptWriter struct {
+	next    Writer
+	aead    cipher.AEAD
+	counter uint32
+	buf     []byte
+}
+
+func Encrypt(key []byte) WriterLayer {
+	return func(next Writer) (WriteCloser, error) {
+		salt := make([]byte, streamSaltSize)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+		aead, err := newStreamGCM(key, salt)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := next.Write(salt); err != nil {
+			return nil, err
+		}
+		ew := &encryptWriter{next: next, aead: aead}
+		return &layerWriter{w: ew, flush: ew.finish, next: next}, nil
+	}
+}
+
+func (w *encryptWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for len(w.buf) > sealChunkSize {
+		if err := w.seal(w.buf[:sealChunkSize], false); err != nil {
+			return 0, err
+		}
+		w.buf = w.buf[sealChunkSize:]
+	}
+	return len(p), nil
+}
+
+// finish seals whatever is buffered as the last chunk, possibly empty.
+func (w *encryptWriter) finish() error {
+	err := w.seal(w.buf, true)
+	w.buf = nil
+	return err
+}
+
+func (w *encryptWriter) seal(chunk []byte, last bool) error {
+	if w.counter == ^uint32(0) {
+		return errors.New("encrypted stream too long")
+	}
+	sealed := w.aead.Seal(nil, chunkNonce(last, w.counter), chunk, nil)
+	w.counter++
+
+	header := make([]byte, 5)
+	if last {
+		header[0] = 1
+	}
+	binary.BigEndian.PutUint32(header[1:], uint32(len(sealed)))
+	if _, err := w.next.Write(header); err != nil {
+		return err
+	}
+	_, err := w.next.Write(sealed)
+	return err
+}
+
+type decryptReader struct {
+	src     Reader
+	aead    cipher.AEAD
+	counter uint32
+	plain   []byte // decrypted, not yet returned
+	done    bool   // the last chunk was read
+}
+
+func Decrypt(key []byte) ReaderLayer {
+	return func(src Reader) (Reader, error) {
+		if _, err := aes.NewCipher(key); err != nil { // a bad key is reported before any reading
+			return nil, err
+		}
+		salt := make([]byte, streamSaltSize)
+		if _, err := io.ReadFull(src, salt); err != nil {
+			return nil, fmt.Errorf("%w: missing header", ErrStreamTruncated)
+		}
+		aead, err := newStreamGCM(key, salt)
+		if err != nil {
+			return nil, err
+		}
+		return &decryptReader{src: src, aead: aead}, nil
+	}
+}
+
+func (r *decryptReader) Read(p []byte) (int, error) {
+	for len(r.plain) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.openChunk(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.plain)
+	r.plain = r.plain[n:]
+	return n, nil
+}
+
+func (r *decryptReader) openChunk() error {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(r.src, header); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return ErrStreamTruncated
+		}
+		return err
+	}
+	last := header[0] == 1
+	size := binary.BigEndian.Uint32(header[1:])
+	if size > sealChunkSize+uint32(r.aead.Overhead()) {
+		return fmt.Errorf("%w: chunk of %d bytes", ErrStreamAuth, size)
+	}
+
+	sealed := make([]byte, size)
+	if _, err := io.ReadFull(r.src, sealed); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return ErrStreamTruncated
+		}
+		return err
+	}
+	plain, err := r.aead.Open(nil, chunkNonce(last, r.counter), sealed, nil)
+	if err != nil {
+		return fmt.Errorf("%w: chunk %d", ErrStreamAuth, r.counter)
+	}
+	if last {
+		// nothing may follow the last chunk, or bytes could be appended unnoticed
+		if _, err := io.ReadFull(r.src, make([]byte, 1)); err == nil {
+			return fmt.Errorf("%w: data after the last chunk", ErrStreamAuth)
+		} else if err != io.EOF {
+			return err
+		}
+	}
+	r.counter++
+	r.plain = plain
+	r.done = last
+	return nil
+}
+
+// Pipeline is a ReadWriter over a file with layers on both sides, so Process can push data
+// through them. The first Read closes the write layers, rewinds the file to where writing
+// started, and reads back through the read layers.
+type Pipeline struct {
+	file   ReadWriteSeeker
+	start  int64
+	w      WriteCloser
+	r      Reader
+	layers []ReaderLayer
+}
+
+func NewPipeline(file ReadWriteSeeker, write []WriterLayer, read []ReaderLayer) (*Pipeline, error) {
+	start, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	w, err := StackWriters(file, write...)
+	if err != nil {
+		return nil, err
+	}
+	return &Pipeline{file: file, start: start, w: w, layers: read}, nil
+}
+
+func (p *Pipeline) Write(b []byte) (int, error) {
+	if p.r != nil {
+		return 0, errors.New("pipeline is already reading")
+	}
+	return p.w.Write(b)
+}
+
+func (p *Pipeline) Read(b []byte) (int, error) {
+	if p.r == nil {
+		if err := p.w.Close(); err != nil {
+			return 0, err
+		}
+		if _, err := p.file.Seek(p.start, io.SeekStart); err != nil {
+			return 0, err
+		}
+		r, err := StackReaders(p.file, p.layers...)
+		if err != nil {
+			return 0, err
+		}
+		p.r = r
+	}
+	return p.r.Read(b)
+}
+
+func StreamTest() {
+	key := make([]byte, 32)
+	rand.Read(key)
+
+	var text strings.Builder
+	for i := range 200 {
+		fmt.Fprintf(&text, "line %03d: the quick brown fox jumps over the lazy dog\n", i)
+	}
+	data := []byte(text.String())
+
+	// compress -> encrypt -> file, and back
+	file := NewMemFile("secret.bin", nil)
+	pipe, _ := NewPipeline(file, []WriterLayer{Gzip(), Encrypt(key)}, []ReaderLayer{Decrypt(key), Gunzip()})
+	out, err := Process(data, pipe)
+	fmt.Printf("gzip+aes-gcm: %d bytes in, %d bytes stored, round trip ok=%v err=%v\n",
+		len(data), file.Size(), string(out) == string(data), err)
+
+	// Any order works: count and checksum the plaintext, encrypt, then base64 for a text channel
+	var counted LineCounter
+	sum := sha256.New()
+	armored := NewMemFile("secret.txt", nil)
+	w, _ := StackWriters(armored, CountLines(&counted), Checksum(sum), Encrypt(key), Base64Encode())
+	w.Write(data)
+	w.Close()
+	fmt.Printf("counted %d lines, %d bytes, sha256 %x..., stored as %d base64 chars\n",
+		counted.Lines, counted.Bytes, sum.Sum(nil)[:6], armored.Size())
+
+	readSum := sha256.New()
+	armored.Seek(0, io.SeekStart)
+	r, _ := StackReaders(armored, Base64Decode(), Decrypt(key), ChecksumRead(readSum))
+	back, err := io.ReadAll(r)
+	fmt.Printf("read back %d bytes, checksums match=%v err=%v\n", len(back), string(readSum.Sum(nil)) == string(sum.Sum(nil)), err)
+
+	// Hex, for eyeballing
+	small := NewMemFile("hex.txt", nil)
+	w, _ = StackWriters(small, HexEncode())
+	w.Write([]byte("Go!"))
+	w.Close()
+	fmt.Printf("hex: %s\n", small.Bytes())
+
+	// Tampering and truncation are detected; gzip reads its header right away, so the
+	// error can already come from StackReaders
+	readAll := func(stored []byte, layers ...ReaderLayer) error {
+		r, err := StackReaders(NewMemFile("stored", stored), layers...)
+		if err == nil {
+			_, err = io.ReadAll(r)
+		}
+		return err
+	}
+	tampered := file.Bytes()
+	tampered[len(tampered)/2] ^= 1
+	fmt.Println("tampered:", readAll(tampered, Decrypt(key), Gunzip()))
+	fmt.Println("truncated:", readAll(file.Bytes()[:file.Size()-10], Decrypt(key)))
+	_, err = StackWriters(file, Encrypt([]byte("short key")))
+	fmt.Println("bad key:", err)
+
+	// Rate limiting: 2 KB at 8 KB/s takes about a quarter of a second
+	start := time.Now()
+	w, _ = StackWriters(io.Discard, RateLimit(8<<10))
+	w.Write(make([]byte, 2<<10))
+	w.Close()
+	elapsed := time.Since(start)
+	fmt.Printf("rate limited write took ~250ms: %v\n", elapsed >= 200*time.Millisecond && elapsed < time.Second)
+}
@@ -0,0 +1,309 @@
+package interface_example
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func encryptBytes(t *testing.T, key, data []byte, closes int) []byte {
+	t.Helper()
+	var out bytes.Buffer
+	w, err := StackWriters(&out, Encrypt(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	for range closes {
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return out.Bytes()
+}
+
+func TestEncryptRoundTrip(t *testing.T) {
+	key := make([]byte, 32)
+	rand.Read(key)
+	data := bytes.Repeat([]byte("stream "), sealChunkSize/3) // a few chunks
+
+	sealed := encryptBytes(t, key, data, 1)
+	r, err := StackReaders(bytes.NewReader(sealed), Decrypt(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	back, err := io.ReadAll(r)
+	if err != nil || !bytes.Equal(back, data) {
+		t.Fatalf("round trip: %d bytes, err=%v; want %d bytes", len(back), err, len(data))
+	}
+}
+
+func TestEncryptUsesFreshSubkeyPerStream(t *testing.T) {
+	key := make([]byte, 16)
+	rand.Read(key)
+	data := []byte("same plaintext")
+
+	a, b := encryptBytes(t, key, data, 1), encryptBytes(t, key, data, 1)
+	if bytes.Equal(a[:streamSaltSize], b[:streamSaltSize]) {
+		t.Fatal("two streams got the same salt")
+	}
+	if bytes.Equal(a[streamSaltSize:], b[streamSaltSize:]) {
+		t.Error("the same plaintext sealed to the same ciphertext in two streams")
+	}
+
+	// the salt is authenticated through the key it derives
+	a[0] ^= 1
+	r, err := StackReaders(bytes.NewReader(a), Decrypt(key))
+	if err == nil {
+		_, err = io.ReadAll(r)
+	}
+	if !errors.Is(err, ErrStreamAuth) {
+		t.Errorf("tampered salt: %v, want ErrStreamAuth", err)
+	}
+}
+
+func TestEncryptCloseIsIdempotent(t *testing.T) {
+	key := make([]byte, 32)
+	rand.Read(key)
+
+	once := encryptBytes(t, key, []byte("hello"), 1)
+	twice := encryptBytes(t, key, []byte("hello"), 2)
+	if len(twice) != len(once) {
+		t.Fatalf("closing twice stored %d bytes, closing once %d", len(twice), len(once))
+	}
+}
+
+func TestDecryptTruncated(t *testing.T) {
+	key := make([]byte, 32)
+	rand.Read(key)
+	sealed := encryptBytes(t, key, []byte("hello, gopher"), 1)
+
+	for _, n := range []int{0, streamSaltSize - 1, streamSaltSize, len(sealed) - 1} {
+		r, err := StackReaders(bytes.NewReader(sealed[:n]), Decrypt(key))
+		if err == nil {
+			_, err = io.ReadAll(r)
+		}
+		if !errors.Is(err, ErrStreamTruncated) && !errors.Is(err, ErrStreamAuth) {
+			t.Errorf("first %d bytes: %v, want a truncation or auth error", n, err)
+		}
+	}
+}
+
+func TestDecryptRejectsTrailingData(t *testing.T) {
+	key := make([]byte, 32)
+	rand.Read(key)
+	sealed := encryptBytes(t, key, []byte("hello, gopher"), 1)
+
+	for _, extra := range [][]byte{{0}, sealed[streamSaltSize:]} {
+		r, err := StackReaders(bytes.NewReader(append(bytes.Clone(sealed), extra...)), Decrypt(key))
+		if err == nil {
+			_, err = io.ReadAll(r)
+		}
+		if !errors.Is(err, ErrStreamAuth) {
+			t.Errorf("%d bytes after the last chunk: %v, want ErrStreamAuth", len(extra), err)
+		}
+	}
+}
+
+func streamText() []byte {
+	var text strings.Builder
+	for i := range 3000 { // enough for a few encryption chunks
+		fmt.Fprintf(&text, "line %04d: the quick brown fox jumps over the lazy dog\n", i)
+	}
+	return []byte(text.String())
+}
+
+func TestLayerRoundTrips(t *testing.T) {
+	key := make([]byte, 32)
+	rand.Read(key)
+	data := streamText()
+
+	tests := []struct {
+		name  string
+		write []WriterLayer
+		read  []ReaderLayer
+	}{
+		{"none", nil, nil},
+		{"gzip", []WriterLayer{Gzip()}, []ReaderLayer{Gunzip()}},
+		{"hex", []WriterLayer{HexEncode()}, []ReaderLayer{HexDecode()}},
+		{"base64", []WriterLayer{Base64Encode()}, []ReaderLayer{Base64Decode()}},
+		{"gzip, encrypt", []WriterLayer{Gzip(), Encrypt(key)}, []ReaderLayer{Decrypt(key), Gunzip()}},
+		{"encrypt, gzip", []WriterLayer{Encrypt(key), Gzip()}, []ReaderLayer{Gunzip(), Decrypt(key)}},
+		{"gzip, encrypt, base64", []WriterLayer{Gzip(), Encrypt(key), Base64Encode()}, []ReaderLayer{Base64Decode(), Decrypt(key), Gunzip()}},
+		{"base64, gzip, hex", []WriterLayer{Base64Encode(), Gzip(), HexEncode()}, []ReaderLayer{HexDecode(), Gunzip(), Base64Decode()}},
+		{"hex, base64, encrypt", []WriterLayer{HexEncode(), Base64Encode(), Encrypt(key)}, []ReaderLayer{Decrypt(key), Base64Decode(), HexDecode()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := NewMemFile("stored", nil)
+			pipe, err := NewPipeline(file, tt.write, tt.read)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, err := Process(data, pipe)
+			if err != nil || !bytes.Equal(out, data) {
+				t.Fatalf("Process = %d bytes, %v; want the %d bytes back", len(out), err, len(data))
+			}
+			if len(tt.write) > 0 && bytes.Equal(file.Bytes(), data) {
+				t.Error("the file holds the plain input")
+			}
+		})
+	}
+}
+
+func TestPipelineStartsAtCurrentOffset(t *testing.T) {
+	file := NewMemFile("stored", []byte("header\n"))
+	file.Seek(0, io.SeekEnd)
+	pipe, err := NewPipeline(file, []WriterLayer{Gzip()}, []ReaderLayer{Gunzip()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := Process([]byte("body"), pipe)
+	if err != nil || string(out) != "body" {
+		t.Fatalf("Process = %q, %v; want body", out, err)
+	}
+	if !bytes.HasPrefix(file.Bytes(), []byte("header\n")) {
+		t.Error("the pipeline overwrote what was before it")
+	}
+	if _, err := pipe.Write([]byte("more")); err == nil {
+		t.Error("Write after Read succeeded")
+	}
+}
+
+func TestCountAndChecksumLayers(t *testing.T) {
+	key := make([]byte, 16)
+	rand.Read(key)
+	data := streamText()
+	want := sha256.Sum256(data)
+
+	var wrote LineCounter
+	writeSum := sha256.New()
+	var stored bytes.Buffer
+	w, err := StackWriters(&stored, CountLines(&wrote), Checksum(writeSum), Gzip(), Encrypt(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(data)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if wrote.Lines != 3000 || wrote.Bytes != int64(len(data)) {
+		t.Errorf("write counter = %+v, want 3000 lines, %d bytes", wrote, len(data))
+	}
+	if !bytes.Equal(writeSum.Sum(nil), want[:]) {
+		t.Error("write checksum doesn't match sha256 of the input")
+	}
+
+	// below the encryption the counter sees ciphertext, not lines
+	var raw LineCounter
+	readSum := sha256.New()
+	var read LineCounter
+	r, err := StackReaders(&stored, CountLinesRead(&raw), Decrypt(key), Gunzip(), ChecksumRead(readSum), CountLinesRead(&read))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		t.Fatal(err)
+	}
+	if read != wrote {
+		t.Errorf("read counter = %+v, write counter %+v", read, wrote)
+	}
+	if raw.Bytes >= int64(len(data)) {
+		t.Errorf("raw counter saw %d bytes, want the compressed size", raw.Bytes)
+	}
+	if !bytes.Equal(readSum.Sum(nil), want[:]) {
+		t.Error("read checksum doesn't match sha256 of the input")
+	}
+}
+
+// fakeClock stands in for time.Now and time.Sleep; sleeping moves it forward.
+type fakeClock struct {
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.sleeps = append(c.sleeps, d)
+	c.now = c.now.Add(d)
+}
+
+func TestRateLimit(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("write", func(t *testing.T) {
+		clock := &fakeClock{now: start}
+		var out bytes.Buffer
+		var sizes []int
+		w, err := StackWriters(WriterFunc(func(p []byte) (int, error) {
+			sizes = append(sizes, len(p))
+			return out.Write(p)
+		}), rateLimit(8<<10, clock.Now, clock.Sleep))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(make([]byte, 2<<10))
+		w.Close()
+
+		// 2 KB at 8 KB/s is a quarter of a second, in pieces of at most 1/20 s
+		if elapsed := clock.now.Sub(start); elapsed != 250*time.Millisecond {
+			t.Errorf("write took %v, want 250ms", elapsed)
+		}
+		for _, d := range clock.sleeps {
+			if d > 50*time.Millisecond {
+				t.Errorf("slept %v at once, want at most 50ms", d)
+			}
+		}
+		for _, n := range sizes {
+			if n > (8<<10)/20 {
+				t.Errorf("wrote %d bytes at once, want at most %d", n, (8<<10)/20)
+			}
+		}
+		if out.Len() != 2<<10 {
+			t.Errorf("wrote %d bytes, want %d", out.Len(), 2<<10)
+		}
+	})
+
+	t.Run("read", func(t *testing.T) {
+		clock := &fakeClock{now: start}
+		r, err := StackReaders(bytes.NewReader(make([]byte, 1<<10)), rateLimitRead(4<<10, clock.Now, clock.Sleep))
+		if err != nil {
+			t.Fatal(err)
+		}
+		back, err := io.ReadAll(r)
+		if err != nil || len(back) != 1<<10 {
+			t.Fatalf("ReadAll = %d bytes, %v", len(back), err)
+		}
+		if elapsed := clock.now.Sub(start); elapsed != 250*time.Millisecond {
+			t.Errorf("read took %v, want 250ms", elapsed)
+		}
+	})
+
+	t.Run("no waiting behind a slow consumer", func(t *testing.T) {
+		clock := &fakeClock{now: start}
+		w, _ := StackWriters(WriterFunc(func(p []byte) (int, error) {
+			clock.now = clock.now.Add(time.Second) // far slower than the limit
+			return len(p), nil
+		}), rateLimit(8<<10, clock.Now, clock.Sleep))
+		w.Write(make([]byte, 1<<10))
+		// the pace is measured from the first piece, so only that one waits
+		if len(clock.sleeps) > 1 {
+			t.Errorf("slept %v behind a slow consumer", clock.sleeps)
+		}
+	})
+
+	if _, err := StackWriters(io.Discard, RateLimit(0)); err == nil {
+		t.Error("RateLimit(0) succeeded")
+	}
+}
@@ -0,0 +1,254 @@
+package interface_example
+
+// Rendering a collection of shapes to SVG. The shapes live in world coordinates
+// (y pointing up, any scale); the canvas fits their combined bounding box into
+// Width x Height, keeping the aspect ratio, and flips y because SVG's y points down.
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+type Style struct {
+	Fill        string // any SVG paint, e.g. "#4e79a7" or "none"
+	Stroke      string
+	StrokeWidth float64 // in canvas pixels, not world units
+	Opacity     float64 // 0 means fully opaque
+}
+
+// defaultPalette colors shapes in turn when no Style func is given.
+var defaultPalette = []string{"#4e79a7", "#f28e2b", "#e15759", "#76b7b2", "#59a14f", "#edc948"}
+
+func defaultStyle(i int, _ Shape) Style {
+	return Style{Fill: defaultPalette[i%len(defaultPalette)], Stroke: "#333333", StrokeWidth: 1, Opacity: 0.7}
+}
+
+type Canvas struct {
+	Width, Height float64
+	Padding       float64
+	Background    string                     // empty means transparent
+	Style         func(i int, s Shape) Style // nil uses the default palette
+}
+
+// viewport maps world coordinates onto the canvas.
+type viewport struct {
+	world   BBox
+	scale   float64
+	offsetX float64
+	offsetY float64
+}
+
+func (c Canvas) viewport(world BBox) viewport {
+	innerW, innerH := c.Width-2*c.Padding, c.Height-2*c.Padding
+	w, h := math.Max(world.Width(), 1e-9), math.Max(world.Height(), 1e-9)
+	scale := math.Min(innerW/w, innerH/h)
+
+	// center the drawing in whichever direction has room to spare
+	return viewport{
+		world:   world,
+		scale:   scale,
+		offsetX: c.Padding + (innerW-world.Width()*scale)/2,
+		offsetY: c.Padding + (innerH-world.Height()*scale)/2,
+	}
+}
+
+func (v viewport) point(p Point) (float64, float64) {
+	return v.offsetX + (p.X-v.world.Min.X)*v.scale, v.offsetY + (v.world.Max.Y-p.Y)*v.scale
+}
+
+// Render writes an SVG document with one element per shape, drawn in order.
+// Every shape is validated first; nothing is written if one is invalid.
+func (c Canvas) Render(w io.Writer, shapes []Shape) error {
+	if !(c.Width > 2*c.Padding) || !(c.Height > 2*c.Padding) {
+		return fmt.Errorf("canvas %vx%v is too small for padding %v", c.Width, c.Height, c.Padding)
+	}
+
+	var world BBox
+	for i, s := range shapes {
+		if err := Validate(s); err != nil {
+			return fmt.Errorf("shape %d: %w", i, err)
+		}
+		b, ok := s.(Bounded)
+		if !ok {
+			return fmt.Errorf("shape %d: %w: %T has no bounding box", i, ErrUnknownShapeType, s)
+		}
+		if i == 0 {
+			world = b.BoundingBox()
+		} else {
+			world = world.Union(b.BoundingBox())
+		}
+	}
+	view := c.viewport(world)
+
+	style := c.Style
+	if style == nil {
+		style = defaultStyle
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s" viewBox="0 0 %s %s">`+"\n",
+		num(c.Width), num(c.Height), num(c.Width), num(c.Height))
+	if c.Background != "" {
+		fmt.Fprintf(bw, `  <rect width="100%%" height="100%%" fill="%s"/>`+"\n", html.EscapeString(c.Background))
+	}
+	for i, s := range shapes {
+		element, err := view.element(s)
+		if err != nil {
+			return fmt.Errorf("shape %d: %w", i, err)
+		}
+		fmt.Fprintf(bw, "  <%s%s/>\n", element, style(i, s).attrs())
+	}
+	fmt.Fprintln(bw, "</svg>")
+	return bw.Flush()
+}
+
+// RenderFile renders shapes into a new SVG file at path.
+func (c Canvas) RenderFile(path string, shapes []Shape) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := c.Render(f, shapes); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// element returns the tag name and geometry attributes for s, without the style.
+func (v viewport) element(s Shape) (string, error) {
+	switch s := s.(type) {
+	case Rectangle:
+		// the rectangle's top-left corner in canvas space is its top-left in world space
+		x, y := v.point(s.Origin.Add(0, s.Height))
+		return fmt.Sprintf(`rect x="%s" y="%s" width="%s" height="%s"`,
+			num(x), num(y), num(s.Width*v.scale), num(s.Height*v.scale)), nil
+	case Circle:
+		cx, cy := v.point(s.Center)
+		return fmt.Sprintf(`circle cx="%s" cy="%s" r="%s"`, num(cx), num(cy), num(s.Radius*v.scale)), nil
+	case Ellipse:
+		cx, cy := v.point(s.Center)
+		el := fmt.Sprintf(`ellipse cx="%s" cy="%s" rx="%s" ry="%s"`, num(cx), num(cy), num(s.RX*v.scale), num(s.RY*v.scale))
+		if s.Rotation != 0 {
+			// counter-clockwise in world space is clockwise once y is flipped
+			el += fmt.Sprintf(` transform="rotate(%s %s %s)"`, num(-s.Rotation*180/math.Pi), num(cx), num(cy))
+		}
+		return el, nil
+	}
+
+	poly, ok := outline(s)
+	if !ok {
+		return "", fmt.Errorf("%w: %T", ErrUnknownShapeType, s)
+	}
+	points := make([]string, len(poly))
+	for i, p := range poly {
+		x, y := v.point(p)
+		points[i] = num(x) + "," + num(y)
+	}
+	return fmt.Sprintf(`polygon points="%s"`, strings.Join(points, " ")), nil
+}
+
+func (st Style) attrs() string {
+	var b strings.Builder
+	fill := st.Fill
+	if fill == "" {
+		fill = "none"
+	}
+	fmt.Fprintf(&b, ` fill="%s"`, html.EscapeString(fill))
+	if st.Stroke != "" {
+		fmt.Fprintf(&b, ` stroke="%s" stroke-width="%s"`, html.EscapeString(st.Stroke), num(st.StrokeWidth))
+	}
+	if st.Opacity > 0 && st.Opacity < 1 {
+		fmt.Fprintf(&b, ` opacity="%s"`, num(st.Opacity))
+	}
+	return b.String()
+}
+
+// num formats a coordinate with at most two decimals and no trailing zeros.
+func num(v float64) string {
+	s := fmt.Sprintf("%.2f", v)
+	s = strings.TrimRight(s, "0")
+	s = strings.TrimSuffix(s, ".")
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+func ShapeFileTest() {
+	dir, err := os.MkdirTemp("", "shapes")
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	house, _ := NewRectangle(Point{0, 0}, 8, 6)
+	roof, _ := NewTriangle(Point{-1, 6}, Point{9, 6}, Point{4, 10})
+	door, _ := NewRectangle(Point{3.5, 0}, 1.5, 3)
+	window, _ := NewRegularPolygon(Point{6, 4}, 8, 1)
+	sun, _ := NewCircle(Point{12, 11}, 1.5)
+	cloud, _ := NewEllipse(Point{-3, 10}, 2.5, 1)
+	path, _ := NewPolygon(Point{3.5, 0}, Point{5, 0}, Point{6, -3}, Point{2.5, -3})
+
+	scene := []Shape{house, roof, door, window, sun, cloud.Rotate(math.Pi / 12), path}
+
+	// Round-trip through a JSON file
+	jsonPath := filepath.Join(dir, "scene.json")
+	if err := SaveShapes(jsonPath, scene); err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	loaded, err := LoadShapes(jsonPath)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	fmt.Printf("saved and reloaded %d shapes:\n", len(loaded))
+	for i, s := range loaded {
+		fmt.Printf("  %-16T same=%v area=%.2f\n", s, reflect.DeepEqual(s, scene[i]), s.Area())
+	}
+
+	sunJSON, _ := MarshalShape(loaded[4])
+	fmt.Println("one shape:", string(sunJSON))
+
+	// Bad input is rejected with a useful error
+	for _, bad := range []string{
+		`{"type":"hexagon","shape":{}}`,
+		`{"type":"circle","shape":{"center":{"x":0,"y":0},"radius":-1}}`,
+		`{"type":"circle","shape":{"radius":"big"}}`,
+	} {
+		_, err := UnmarshalShape([]byte(bad))
+		fmt.Println("rejected:", err)
+	}
+
+	// Render to SVG, the house in earthy colors and the rest from the palette
+	canvas := Canvas{
+		Width: 400, Height: 300, Padding: 20, Background: "#eef6ff",
+		Style: func(i int, s Shape) Style {
+			switch i {
+			case 0:
+				return Style{Fill: "#d9b38c", Stroke: "#5c4033", StrokeWidth: 2}
+			case 1:
+				return Style{Fill: "#a0522d", Stroke: "#5c4033", StrokeWidth: 2}
+			case 4:
+				return Style{Fill: "#ffd700"}
+			}
+			return defaultStyle(i, s)
+		},
+	}
+	svgPath := filepath.Join(dir, "scene.svg")
+	if err := canvas.RenderFile(svgPath, loaded); err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	svg, _ := os.ReadFile(svgPath)
+	fmt.Print(string(svg))
+}
@@ -0,0 +1,24 @@
+package interface_example
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestRenderValidatesShapes(t *testing.T) {
+	var buf bytes.Buffer
+	canvas := Canvas{Width: 100, Height: 100}
+
+	err := canvas.Render(&buf, []Shape{Circle{Radius: 1}, Polygon{}})
+	if !errors.Is(err, ErrInvalidShape) {
+		t.Fatalf("Render with an empty polygon = %v, want ErrInvalidShape", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Render wrote %d bytes before failing", buf.Len())
+	}
+
+	if err := canvas.Render(&buf, []Shape{Circle{Radius: 1}}); err != nil {
+		t.Errorf("Render(valid) = %v", err)
+	}
+}
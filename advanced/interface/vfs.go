@@ -0,0 +1,790 @@
+package interface_example
+
+// A virtual filesystem: code that takes a FileSystem instead of calling os directly can run
+// against the real disk, against memory in tests, or against an overlay that keeps a base
+// tree read-only and records every change on top of it.
+//
+// FileSystem extends io/fs.FS, so fs.ReadFile, fs.WalkDir, fs.Glob, fs.Sub and http.FS all
+// work with every backend. Names follow io/fs rules: slash-separated, relative, no "." or
+// ".." elements ("docs/readme.txt", not "/docs/readme.txt"); the root is ".".
+//
+// OSFS     - a directory on disk
+// MemFS    - a tree kept in memory
+// OverlayFS - reads fall through from an upper to a lower filesystem; writes only go to the
+//            upper one. A lower file opened for writing is first copied up (copy-on-write),
+//            and a removed lower file is hidden by a "whiteout" instead of deleted.
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrIsDir       = errors.New("is a directory")
+	ErrNotDir      = errors.New("not a directory")
+	ErrDirNotEmpty = errors.New("directory not empty")
+)
+
+// WritableFile is what OpenFile and Create return.
+type WritableFile interface {
+	fs.File
+	Writer
+	Seeker
+}
+
+type FileSystem interface {
+	fs.FS
+	fs.StatFS
+	fs.ReadDirFS
+
+	// OpenFile takes the os.O_* flags: O_RDONLY, O_WRONLY or O_RDWR, plus O_CREATE,
+	// O_EXCL, O_TRUNC and O_APPEND.
+	OpenFile(name string, flag int) (WritableFile, error)
+	// Create opens name for reading and writing, truncating or creating it; the
+	// parent directory must exist.
+	Create(name string) (WritableFile, error)
+	// Remove deletes a file or an empty directory.
+	Remove(name string) error
+	MkdirAll(name string) error
+}
+
+// WriteFile is os.WriteFile for any FileSystem.
+func WriteFile(fsys FileSystem, name string, data []byte) error {
+	f, err := fsys.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	return errors.Join(err, f.Close())
+}
+
+func pathError(op, name string, err error) error {
+	return &fs.PathError{Op: op, Path: name, Err: err}
+}
+
+func checkName(op, name string) error {
+	if !fs.ValidPath(name) {
+		return pathError(op, name, fs.ErrInvalid)
+	}
+	return nil
+}
+
+func canWrite(flag int) bool {
+	return flag&(os.O_WRONLY|os.O_RDWR) != 0
+}
+
+func createFile(fsys FileSystem, name string) (WritableFile, error) {
+	return fsys.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC)
+}
+
+// OSFS
+
+type OSFS struct {
+	root string
+}
+
+// NewOSFS serves the directory root. Like os.DirFS, it keeps names inside root but
+// follows symlinks wherever they point.
+func NewOSFS(root string) *OSFS {
+	return &OSFS{root: root}
+}
+
+func (o *OSFS) path(op, name string) (string, error) {
+	if err := checkName(op, name); err != nil {
+		return "", err
+	}
+	return filepath.Join(o.root, filepath.FromSlash(name)), nil
+}
+
+func (o *OSFS) Open(name string) (fs.File, error) {
+	p, err := o.path("open", name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err // a nil *os.File in an fs.File would not compare equal to nil
+	}
+	return f, nil
+}
+
+func (o *OSFS) OpenFile(name string, flag int) (WritableFile, error) {
+	p, err := o.path("open", name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(p, flag, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (o *OSFS) Create(name string) (WritableFile, error) {
+	return createFile(o, name)
+}
+
+func (o *OSFS) Stat(name string) (fs.FileInfo, error) {
+	p, err := o.path("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return os.Stat(p)
+}
+
+func (o *OSFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	p, err := o.path("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadDir(p)
+}
+
+func (o *OSFS) Remove(name string) error {
+	if name == "." {
+		return pathError("remove", name, fs.ErrInvalid)
+	}
+	p, err := o.path("remove", name)
+	if err != nil {
+		return err
+	}
+	return os.Remove(p)
+}
+
+func (o *OSFS) MkdirAll(name string) error {
+	p, err := o.path("mkdir", name)
+	if err != nil {
+		return err
+	}
+	return os.MkdirAll(p, 0o755)
+}
+
+// MemFS
+
+type memNode struct {
+	name     string
+	dir      bool
+	data     []byte
+	modTime  time.Time
+	children map[string]*memNode
+}
+
+func (n *memNode) info() fs.FileInfo {
+	return memInfo{name: n.name, size: int64(len(n.data)), dir: n.dir, modTime: n.modTime}
+}
+
+func (n *memNode) entries() []fs.DirEntry {
+	out := make([]fs.DirEntry, 0, len(n.children))
+	for _, c := range n.children {
+		out = append(out, fs.FileInfoToDirEntry(c.info()))
+	}
+	slices.SortFunc(out, func(a, b fs.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+	return out
+}
+
+type memInfo struct {
+	name    string
+	size    int64
+	dir     bool
+	modTime time.Time
+}
+
+func (i memInfo) Name() string       { return i.name }
+func (i memInfo) Size() int64        { return i.size }
+func (i memInfo) ModTime() time.Time { return i.modTime }
+func (i memInfo) IsDir() bool        { return i.dir }
+func (i memInfo) Sys() any           { return nil }
+
+func (i memInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0o755
+	}
+	return 0o644
+}
+
+type MemFS struct {
+	mu   sync.RWMutex
+	root *memNode
+}
+
+func NewMemFS() *MemFS {
+	return &MemFS{root: &memNode{name: ".", dir: true, modTime: time.Now(), children: map[string]*memNode{}}}
+}
+
+// lookup finds a node; callers hold m.mu.
+func (m *MemFS) lookup(op, name string) (*memNode, error) {
+	if err := checkName(op, name); err != nil {
+		return nil, err
+	}
+	n := m.root
+	if name == "." {
+		return n, nil
+	}
+	for _, part := range strings.Split(name, "/") {
+		if !n.dir {
+			return nil, pathError(op, name, ErrNotDir)
+		}
+		child, ok := n.children[part]
+		if !ok {
+			return nil, pathError(op, name, fs.ErrNotExist)
+		}
+		n = child
+	}
+	return n, nil
+}
+
+func (m *MemFS) Open(name string) (fs.File, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	n, err := m.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+	if n.dir {
+		return &dirFile{name: name, info: n.info(), entries: n.entries()}, nil
+	}
+	return &memHandle{fs: m, node: n, name: name, read: true}, nil
+}
+
+func (m *MemFS) OpenFile(name string, flag int) (WritableFile, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n, err := m.lookup("open", name)
+	switch {
+	case err == nil:
+		if flag&os.O_CREATE != 0 && flag&os.O_EXCL != 0 {
+			return nil, pathError("open", name, fs.ErrExist)
+		}
+		if n.dir && canWrite(flag) {
+			return nil, pathError("open", name, ErrIsDir)
+		}
+		if flag&os.O_TRUNC != 0 && canWrite(flag) {
+			n.data = nil
+			n.modTime = time.Now()
+		}
+
+	case errors.Is(err, fs.ErrNotExist) && flag&os.O_CREATE != 0:
+		parent, perr := m.lookup("open", path.Dir(name))
+		if perr != nil {
+			return nil, pathError("open", name, fs.ErrNotExist)
+		}
+		if !parent.dir {
+			return nil, pathError("open", name, ErrNotDir)
+		}
+		n = &memNode{name: path.Base(name), modTime: time.Now()}
+		parent.children[n.name] = n
+
+	default:
+		return nil, err
+	}
+
+	if n.dir {
+		// read-only open of a directory: a WritableFile whose writes fail
+		return &memHandle{fs: m, node: n, name: name, read: true}, nil
+	}
+	access := flag & (os.O_RDONLY | os.O_WRONLY | os.O_RDWR)
+	return &memHandle{
+		fs:     m,
+		node:   n,
+		name:   name,
+		read:   access != os.O_WRONLY,
+		write:  canWrite(flag),
+		append: flag&os.O_APPEND != 0,
+	}, nil
+}
+
+func (m *MemFS) Create(name string) (WritableFile, error) {
+	return createFile(m, name)
+}
+
+func (m *MemFS) Stat(name string) (fs.FileInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	n, err := m.lookup("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return n.info(), nil
+}
+
+func (m *MemFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	n, err := m.lookup("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	if !n.dir {
+		return nil, pathError("readdir", name, ErrNotDir)
+	}
+	return n.entries(), nil
+}
+
+func (m *MemFS) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if name == "." {
+		return pathError("remove", name, fs.ErrInvalid)
+	}
+	n, err := m.lookup("remove", name)
+	if err != nil {
+		return err
+	}
+	if n.dir && len(n.children) > 0 {
+		return pathError("remove", name, ErrDirNotEmpty)
+	}
+	parent, _ := m.lookup("remove", path.Dir(name))
+	delete(parent.children, n.name)
+	return nil
+}
+
+func (m *MemFS) MkdirAll(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := checkName("mkdir", name); err != nil {
+		return err
+	}
+	if name == "." {
+		return nil
+	}
+	n := m.root
+	for _, part := range strings.Split(name, "/") {
+		child, ok := n.children[part]
+		if !ok {
+			child = &memNode{name: part, dir: true, modTime: time.Now(), children: map[string]*memNode{}}
+			n.children[part] = child
+		} else if !child.dir {
+			return pathError("mkdir", name, ErrNotDir)
+		}
+		n = child
+	}
+	return nil
+}
+
+// memHandle is an open MemFS file. Handles share the node, so a write is visible to
+// every other handle at once, as with a real file.
+type memHandle struct {
+	fs     *MemFS
+	node   *memNode
+	name   string
+	offset int64
+	read   bool
+	write  bool
+	append bool
+	closed bool
+}
+
+func (h *memHandle) Read(p []byte) (int, error) {
+	if h.closed {
+		return 0, pathError("read", h.name, fs.ErrClosed)
+	}
+	if h.node.dir {
+		return 0, pathError("read", h.name, ErrIsDir)
+	}
+	if !h.read {
+		return 0, pathError("read", h.name, fs.ErrPermission)
+	}
+
+	h.fs.mu.RLock()
+	defer h.fs.mu.RUnlock()
+	if h.offset >= int64(len(h.node.data)) {
+		if len(p) == 0 {
+			return 0, nil
+		}
+		return 0, io.EOF
+	}
+	n := copy(p, h.node.data[h.offset:])
+	h.offset += int64(n)
+	return n, nil
+}
+
+func (h *memHandle) Write(p []byte) (int, error) {
+	if h.closed {
+		return 0, pathError("write", h.name, fs.ErrClosed)
+	}
+	if !h.write {
+		return 0, pathError("write", h.name, fs.ErrPermission)
+	}
+
+	h.fs.mu.Lock()
+	defer h.fs.mu.Unlock()
+	if h.append {
+		h.offset = int64(len(h.node.data))
+	}
+	end := h.offset + int64(len(p))
+	if end > int64(len(h.node.data)) {
+		h.node.data = append(h.node.data, make([]byte, end-int64(len(h.node.data)))...)
+	}
+	copy(h.node.data[h.offset:], p)
+	h.offset = end
+	h.node.modTime = time.Now()
+	return len(p), nil
+}
+
+func (h *memHandle) Seek(offset int64, whence int) (int64, error) {
+	if h.closed {
+		return 0, pathError("seek", h.name, fs.ErrClosed)
+	}
+	h.fs.mu.RLock()
+	size := int64(len(h.node.data))
+	h.fs.mu.RUnlock()
+
+	pos, err := seekOffset(offset, whence, h.offset, size)
+	if err != nil {
+		return h.offset, pathError("seek", h.name, err)
+	}
+	h.offset = pos
+	return pos, nil
+}
+
+func (h *memHandle) Stat() (fs.FileInfo, error) {
+	if h.closed {
+		return nil, pathError("stat", h.name, fs.ErrClosed)
+	}
+	h.fs.mu.RLock()
+	defer h.fs.mu.RUnlock()
+	return h.node.info(), nil
+}
+
+func (h *memHandle) Close() error {
+	if h.closed {
+		return pathError("close", h.name, fs.ErrClosed)
+	}
+	h.closed = true
+	return nil
+}
+
+// dirFile is an open directory: a listing taken at Open time, read with ReadDir.
+type dirFile struct {
+	name    string
+	info    fs.FileInfo
+	entries []fs.DirEntry
+	pos     int
+	closed  bool
+}
+
+func (d *dirFile) Read([]byte) (int, error) {
+	return 0, pathError("read", d.name, ErrIsDir)
+}
+
+func (d *dirFile) Stat() (fs.FileInfo, error) {
+	return d.info, nil
+}
+
+// ReadDir follows fs.ReadDirFile: n > 0 returns at most n entries and io.EOF at the end,
+// n <= 0 returns all remaining entries.
+func (d *dirFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	if d.closed {
+		return nil, pathError("readdir", d.name, fs.ErrClosed)
+	}
+	rest := d.entries[d.pos:]
+	if n <= 0 {
+		d.pos = len(d.entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(rest))
+	d.pos += n
+	return rest[:n], nil
+}
+
+func (d *dirFile) Close() error {
+	if d.closed {
+		return pathError("close", d.name, fs.ErrClosed)
+	}
+	d.closed = true
+	return nil
+}
+
+// OverlayFS
+
+type OverlayFS struct {
+	lower FileSystem
+	upper FileSystem
+
+	mu        sync.Mutex
+	whiteouts map[string]bool // lower names removed through the overlay; kept in memory only
+}
+
+func NewOverlayFS(lower, upper FileSystem) *OverlayFS {
+	return &OverlayFS{lower: lower, upper: upper, whiteouts: map[string]bool{}}
+}
+
+// hidden reports whether name or one of its parents was removed through the overlay.
+func (o *OverlayFS) hidden(name string) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for p := name; p != "."; p = path.Dir(p) {
+		if o.whiteouts[p] {
+			return true
+		}
+	}
+	return false
+}
+
+// reveal drops the whiteouts of name and its parents once they exist in the upper layer.
+func (o *OverlayFS) reveal(name string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for p := name; p != "."; p = path.Dir(p) {
+		delete(o.whiteouts, p)
+	}
+}
+
+// stat finds name in the merged view and says which layer it comes from.
+func (o *OverlayFS) stat(op, name string) (fs.FileInfo, FileSystem, error) {
+	if err := checkName(op, name); err != nil {
+		return nil, nil, err
+	}
+	if info, err := o.upper.Stat(name); err == nil {
+		return info, o.upper, nil
+	}
+	if o.hidden(name) {
+		return nil, nil, pathError(op, name, fs.ErrNotExist)
+	}
+	info, err := o.lower.Stat(name)
+	if err != nil {
+		return nil, nil, pathError(op, name, fs.ErrNotExist)
+	}
+	return info, o.lower, nil
+}
+
+func (o *OverlayFS) Stat(name string) (fs.FileInfo, error) {
+	info, _, err := o.stat("stat", name)
+	return info, err
+}
+
+func (o *OverlayFS) Open(name string) (fs.File, error) {
+	info, layer, err := o.stat("open", name)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		entries, err := o.ReadDir(name)
+		if err != nil {
+			return nil, err
+		}
+		return &dirFile{name: name, info: info, entries: entries}, nil
+	}
+	return layer.Open(name)
+}
+
+// ReadDir merges both layers; an upper entry shadows a lower one with the same name.
+func (o *OverlayFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	info, _, err := o.stat("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, pathError("readdir", name, ErrNotDir)
+	}
+
+	merged := map[string]fs.DirEntry{}
+	if !o.hidden(name) {
+		if entries, err := o.lower.ReadDir(name); err == nil {
+			for _, e := range entries {
+				if !o.hidden(path.Join(name, e.Name())) {
+					merged[e.Name()] = e
+				}
+			}
+		}
+	}
+	if entries, err := o.upper.ReadDir(name); err == nil {
+		for _, e := range entries {
+			merged[e.Name()] = e
+		}
+	}
+
+	out := make([]fs.DirEntry, 0, len(merged))
+	for _, e := range merged {
+		out = append(out, e)
+	}
+	slices.SortFunc(out, func(a, b fs.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+	return out, nil
+}
+
+func (o *OverlayFS) OpenFile(name string, flag int) (WritableFile, error) {
+	info, layer, err := o.stat("open", name)
+	exists := err == nil
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	switch {
+	case !canWrite(flag) && exists:
+		return layer.OpenFile(name, flag)
+	case !exists && flag&os.O_CREATE == 0:
+		return nil, err
+	case exists && flag&os.O_CREATE != 0 && flag&os.O_EXCL != 0:
+		return nil, pathError("open", name, fs.ErrExist)
+	case exists && info.IsDir():
+		return nil, pathError("open", name, ErrIsDir)
+	}
+
+	// writing: make sure the file lives in the upper layer
+	if err := o.MkdirAll(path.Dir(name)); err != nil {
+		return nil, err
+	}
+	if exists && layer == o.lower {
+		if flag&os.O_TRUNC == 0 {
+			if err := o.copyUp(name); err != nil {
+				return nil, err
+			}
+		}
+		flag |= os.O_CREATE // truncating skips the copy, so the upper file may not exist yet
+	}
+	f, err := o.upper.OpenFile(name, flag)
+	if err != nil {
+		return nil, err
+	}
+	o.reveal(name)
+	return f, nil
+}
+
+func (o *OverlayFS) copyUp(name string) error {
+	data, err := fs.ReadFile(o.lower, name)
+	if err != nil {
+		return err
+	}
+	return WriteFile(o.upper, name, data)
+}
+
+func (o *OverlayFS) Create(name string) (WritableFile, error) {
+	return createFile(o, name)
+}
+
+func (o *OverlayFS) Remove(name string) error {
+	if name == "." {
+		return pathError("remove", name, fs.ErrInvalid)
+	}
+	info, _, err := o.stat("remove", name)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		entries, err := o.ReadDir(name)
+		if err != nil {
+			return err
+		}
+		if len(entries) > 0 {
+			return pathError("remove", name, ErrDirNotEmpty)
+		}
+	}
+
+	if err := o.upper.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if _, err := o.lower.Stat(name); err == nil {
+		o.mu.Lock()
+		o.whiteouts[name] = true
+		o.mu.Unlock()
+	}
+	return nil
+}
+
+func (o *OverlayFS) MkdirAll(name string) error {
+	if err := checkName("mkdir", name); err != nil {
+		return err
+	}
+	for p := name; p != "."; p = path.Dir(p) {
+		if info, _, err := o.stat("mkdir", p); err == nil {
+			if !info.IsDir() {
+				return pathError("mkdir", name, ErrNotDir)
+			}
+		}
+	}
+	if err := o.upper.MkdirAll(name); err != nil {
+		return err
+	}
+	o.reveal(name)
+	return nil
+}
+
+func listFiles(fsys fs.FS) []string {
+	var names []string
+	fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			names = append(names, p)
+		}
+		return err
+	})
+	return names
+}
+
+// seedDocs is ordinary code written against the interface; it doesn't care where the files go.
+func seedDocs(fsys FileSystem) error {
+	if err := fsys.MkdirAll("docs/guides"); err != nil {
+		return err
+	}
+	files := map[string]string{
+		"README.md":               "# Project\n",
+		"docs/intro.txt":          "Interfaces decouple code from storage.\n",
+		"docs/guides/install.txt": "go install ./...\n",
+	}
+	for name, content := range files {
+		if err := WriteFile(fsys, name, []byte(content)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func VFSTest() {
+	dir, err := os.MkdirTemp("", "vfs")
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	osfs, mem := NewOSFS(dir), NewMemFS()
+	for _, fsys := range []FileSystem{osfs, mem} {
+		if err := seedDocs(fsys); err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		fmt.Printf("%-10T files=%v\n", fsys, listFiles(fsys))
+	}
+
+	// Process from the composition example works on any backend's files
+	f, _ := mem.Create("scratch.txt")
+	out, err := Process([]byte("Hello, VFS!"), f)
+	f.Close()
+	fmt.Printf("Process on a MemFS file: %q err=%v\n", out, err)
+
+	// Overlay: the directory on disk stays untouched, changes land in memory
+	overlay := NewOverlayFS(osfs, NewMemFS())
+
+	readme, _ := overlay.OpenFile("README.md", os.O_WRONLY|os.O_APPEND)
+	readme.Write([]byte("Edited through the overlay.\n"))
+	readme.Close()
+	WriteFile(overlay, "docs/guides/faq.txt", []byte("Q: Does disk change? A: No.\n"))
+	overlay.Remove("docs/intro.txt")
+
+	merged, _ := fs.ReadFile(overlay, "README.md")
+	onDisk, _ := os.ReadFile(filepath.Join(dir, "README.md"))
+	fmt.Printf("overlay README: %q\ndisk README:    %q\n", merged, onDisk)
+	fmt.Println("overlay files:", listFiles(overlay))
+	fmt.Println("disk files:   ", listFiles(osfs))
+
+	_, err = overlay.Stat("docs/intro.txt")
+	fmt.Println("removed file:", err, errors.Is(err, fs.ErrNotExist))
+	fmt.Println("non-empty dir:", overlay.Remove("docs"))
+	_, err = mem.Open("/etc/passwd")
+	fmt.Println("invalid name:", err)
+}
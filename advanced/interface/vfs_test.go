@@ -0,0 +1,182 @@
+package interface_example
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+var seededFiles = []string{"README.md", "docs/guides/install.txt", "docs/intro.txt"}
+
+func seeded(t *testing.T, fsys FileSystem) FileSystem {
+	t.Helper()
+	if err := seedDocs(fsys); err != nil {
+		t.Fatal(err)
+	}
+	return fsys
+}
+
+func readString(t *testing.T, fsys fs.FS, name string) string {
+	t.Helper()
+	b, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestMemFS(t *testing.T) {
+	fsys := seeded(t, NewMemFS())
+	if err := fstest.TestFS(fsys, seededFiles...); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.Open("/etc/passwd"); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("Open with an absolute name = %v, want fs.ErrInvalid", err)
+	}
+}
+
+func TestOSFS(t *testing.T) {
+	dir := t.TempDir()
+	fsys := seeded(t, NewOSFS(dir))
+	if err := fstest.TestFS(fsys, seededFiles...); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := os.ReadFile(filepath.Join(dir, "docs", "intro.txt")); err != nil || len(got) == 0 {
+		t.Errorf("file on disk = %q, %v", got, err)
+	}
+}
+
+// overlay returns an OverlayFS over a seeded MemFS, with the lower layer for checking
+// that it's never written to.
+func overlay(t *testing.T) (*OverlayFS, FileSystem) {
+	t.Helper()
+	lower := seeded(t, NewMemFS())
+	return NewOverlayFS(lower, NewMemFS()), lower
+}
+
+func TestOverlayFS(t *testing.T) {
+	o, _ := overlay(t)
+	if err := WriteFile(o, "docs/guides/faq.txt", []byte("Q?\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Remove("docs/intro.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := fstest.TestFS(o, "README.md", "docs/guides/faq.txt", "docs/guides/install.txt"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOverlayCopyUp(t *testing.T) {
+	o, lower := overlay(t)
+
+	f, err := o.OpenFile("README.md", os.O_WRONLY|os.O_APPEND)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("more\n")); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	if got := readString(t, o, "README.md"); got != "# Project\nmore\n" {
+		t.Errorf("overlay README = %q", got)
+	}
+	if got := readString(t, lower, "README.md"); got != "# Project\n" {
+		t.Errorf("lower README changed to %q", got)
+	}
+	if got := readString(t, o.upper, "README.md"); got != "# Project\nmore\n" {
+		t.Errorf("upper README = %q", got)
+	}
+}
+
+func TestOverlayOpenFileFlags(t *testing.T) {
+	tests := []struct {
+		name string
+		flag int
+		want string
+	}{
+		{"truncate", os.O_WRONLY | os.O_TRUNC, "new\n"},
+		{"truncate read-write", os.O_RDWR | os.O_TRUNC, "new\n"},
+		{"append", os.O_WRONLY | os.O_APPEND, "# Project\nnew\n"},
+		{"overwrite", os.O_WRONLY, "new\noject\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, lower := overlay(t)
+			f, err := o.OpenFile("README.md", tt.flag)
+			if err != nil {
+				t.Fatalf("OpenFile(%s) on a lower file: %v", tt.name, err)
+			}
+			if _, err := f.Write([]byte("new\n")); err != nil {
+				t.Fatal(err)
+			}
+			f.Close()
+
+			if got := readString(t, o, "README.md"); got != tt.want {
+				t.Errorf("README = %q, want %q", got, tt.want)
+			}
+			if got := readString(t, lower, "README.md"); got != "# Project\n" {
+				t.Errorf("lower README changed to %q", got)
+			}
+		})
+	}
+}
+
+func TestOverlayOpenFileErrors(t *testing.T) {
+	o, _ := overlay(t)
+	if _, err := o.OpenFile("missing.txt", os.O_WRONLY); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("write-open of a missing file = %v, want fs.ErrNotExist", err)
+	}
+	if _, err := o.OpenFile("README.md", os.O_WRONLY|os.O_CREATE|os.O_EXCL); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("O_EXCL on a lower file = %v, want fs.ErrExist", err)
+	}
+	if _, err := o.OpenFile("docs", os.O_WRONLY); !errors.Is(err, ErrIsDir) {
+		t.Errorf("write-open of a directory = %v, want ErrIsDir", err)
+	}
+}
+
+func TestOverlayWhiteouts(t *testing.T) {
+	o, lower := overlay(t)
+
+	if err := o.Remove("docs/intro.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := o.Stat("docs/intro.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Stat of a removed file = %v, want fs.ErrNotExist", err)
+	}
+	if _, err := lower.Stat("docs/intro.txt"); err != nil {
+		t.Errorf("lower file is gone: %v", err)
+	}
+	if got := listFiles(o); !slices.Equal(got, []string{"README.md", "docs/guides/install.txt"}) {
+		t.Errorf("overlay files = %v", got)
+	}
+
+	// a non-empty directory can't be removed, an emptied one can and hides its lower copy
+	if err := o.Remove("docs"); !errors.Is(err, ErrDirNotEmpty) {
+		t.Errorf("Remove of a non-empty dir = %v, want ErrDirNotEmpty", err)
+	}
+	o.Remove("docs/guides/install.txt")
+	o.Remove("docs/guides")
+	if err := o.Remove("docs"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := o.Stat("docs/guides/install.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("file under a removed dir = %v, want fs.ErrNotExist", err)
+	}
+
+	// writing the name again brings it back with the new content only
+	if err := WriteFile(o, "docs/intro.txt", []byte("again\n")); err != nil {
+		t.Fatal(err)
+	}
+	if got := readString(t, o, "docs/intro.txt"); got != "again\n" {
+		t.Errorf("recreated file = %q", got)
+	}
+	if got := listFiles(o); !slices.Equal(got, []string{"README.md", "docs/intro.txt"}) {
+		t.Errorf("overlay files after recreating = %v", got)
+	}
+}
@@ -2,6 +2,16 @@ package account
 
 import (
 	"errors"
+	"slices"
+	"sync"
+	"time"
+)
+
+var (
+	ErrAccountNotFound   = errors.New("account not found")
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	ErrSameAccount       = errors.New("cannot transfer to the same account")
+	ErrHoldNotFound      = errors.New("hold not found")
 )
 
 type Account struct {
@@ -10,52 +20,275 @@ type Account struct {
 	Name    string
 }
 
+// Transaction is one line of an account statement.
+type Transaction struct {
+	Kind         string    `json:"kind"` // deposit, withdraw, transfer_in, transfer_out
+	Amount       float64   `json:"amount"`
+	Balance      float64   `json:"balance"` // balance after the transaction
+	Counterparty int       `json:"counterparty,omitempty"`
+	Time         time.Time `json:"time"`
+}
+
+// Hold is a withdrawal or transfer that a rule sent to review. It runs only once approved.
+type Hold struct {
+	ID     int
+	From   int
+	To     int // 0 for a withdrawal
+	Amount float64
+	Rule   string
+	Reason string
+	Time   time.Time
+}
+
+// op names the operation for a RuleError.
+func (h Hold) op() string {
+	if h.To == 0 {
+		return "withdrawal"
+	}
+	return "transfer"
+}
+
 type Manager struct {
-	accounts []Account
+	mu           sync.Mutex
+	accounts     []Account
+	nextID       int // IDs are never reused, so they can't be derived from len(accounts)
+	transactions map[int][]Transaction
+	rules        *RuleEngine // optional checks before a withdrawal
+	holds        map[int]Hold
+	nextHoldID   int
+	clock        Clock // stamps transactions
 }
 
 func NewManager() *Manager {
-	return &Manager{accounts: []Account{}}
+	return &Manager{accounts: []Account{}, nextID: 1, transactions: map[int][]Transaction{}, holds: map[int]Hold{}, clock: RealClock()}
+}
+
+// SetClock replaces the clock used to stamp transactions, e.g. with the FakeClock
+// that drives an InterestEngine or Scheduler.
+func (m *Manager) SetClock(clock Clock) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.clock = clock
 }
 
 func (m *Manager) OpenAccount(name string) (a Account) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	a = Account{
-		ID:      len(m.accounts) + 1,
+		ID:      m.nextID,
 		Balance: 0.0,
 		Name:    name,
 	}
-	m.accounts = append(m.accounts, a)
+	m.insert(a)
 	return a
 }
 
+// insert adds an account with a known ID and keeps the ID sequence ahead of it.
+// The caller must hold m.mu (or own m exclusively, like Store does).
+func (m *Manager) insert(a Account) {
+	m.accounts = append(m.accounts, a)
+	if a.ID >= m.nextID {
+		m.nextID = a.ID + 1
+	}
+}
+
 func (m *Manager) GetBalance(id int) (float64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	for _, a := range m.accounts {
 		if a.ID == id {
 			return a.Balance, nil
 		}
 	}
-	return 0.0, errors.New("account not found")
+	return 0.0, ErrAccountNotFound
 }
 
 func (m *Manager) Deposit(id int, amount float64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	for i := range m.accounts {
 		if m.accounts[i].ID == id {
 			m.accounts[i].Balance += amount
+			m.record(i, "deposit", amount, 0)
 			return nil
 		}
 	}
-	return errors.New("account not found")
+	return ErrAccountNotFound
+}
+
+// SetRules installs the rules that every withdrawal and outgoing transfer must pass; nil removes them.
+func (m *Manager) SetRules(rules *RuleEngine) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.rules = rules
 }
 
 func (m *Manager) WithDraw(id int, amount float64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.withdraw(id, amount, false)
+}
+
+func (m *Manager) withdraw(id int, amount float64, approved bool) error {
 	for i := range m.accounts {
 		if m.accounts[i].ID == id {
+			w, err := m.checkRules(Hold{From: id, Amount: amount}, approved)
+			if err != nil {
+				return err
+			}
+
 			if m.accounts[i].Balance < amount {
-				return errors.New("insufficient funds")
+				return ErrInsufficientFunds
 			}
 			m.accounts[i].Balance -= amount
+			m.record(i, "withdraw", amount, 0)
+
+			m.observeRules(w)
 			return nil
 		}
 	}
-	return errors.New("account not found")
+	return ErrAccountNotFound
+}
+
+// Transfer moves amount between two accounts atomically: either both balances change or neither does.
+// The rules see it as a withdrawal from the source account.
+func (m *Manager) Transfer(from, to int, amount float64) error {
+	if from == to {
+		return ErrSameAccount
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.transfer(from, to, amount, false)
+}
+
+func (m *Manager) transfer(from, to int, amount float64, approved bool) error {
+	src, dst := -1, -1
+	for i := range m.accounts {
+		switch m.accounts[i].ID {
+		case from:
+			src = i
+		case to:
+			dst = i
+		}
+	}
+	if src < 0 || dst < 0 {
+		return ErrAccountNotFound
+	}
+
+	w, err := m.checkRules(Hold{From: from, To: to, Amount: amount}, approved)
+	if err != nil {
+		return err
+	}
+	if m.accounts[src].Balance < amount {
+		return ErrInsufficientFunds
+	}
+
+	m.accounts[src].Balance -= amount
+	m.accounts[dst].Balance += amount
+	m.record(src, "transfer_out", amount, to)
+	m.record(dst, "transfer_in", amount, from)
+
+	m.observeRules(w)
+	return nil
+}
+
+// checkRules runs the rules on money leaving h.From. A Review decision parks h as a hold
+// and returns a *RuleError carrying its ID. The caller must hold m.mu.
+func (m *Manager) checkRules(h Hold, approved bool) (Withdrawal, error) {
+	if m.rules == nil {
+		return Withdrawal{}, nil
+	}
+
+	w, err := m.rules.check(h.op(), h.From, h.Amount, approved)
+	var ruleErr *RuleError
+	if errors.As(err, &ruleErr) && ruleErr.Decision == Review {
+		m.nextHoldID++
+		h.ID, h.Rule, h.Reason, h.Time = m.nextHoldID, ruleErr.Rule, ruleErr.Reason, w.Time
+		m.holds[h.ID] = h
+		ruleErr.HoldID = h.ID
+	}
+	return w, err
+}
+
+// observeRules tells the rules that a checked withdrawal went through; the caller must hold m.mu.
+func (m *Manager) observeRules(w Withdrawal) {
+	if m.rules != nil {
+		m.rules.observe(w)
+	}
+}
+
+// Holds returns the operations waiting for review, oldest first.
+func (m *Manager) Holds() []Hold {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	holds := make([]Hold, 0, len(m.holds))
+	for _, h := range m.holds {
+		holds = append(holds, h)
+	}
+	slices.SortFunc(holds, func(a, b Hold) int { return a.ID - b.ID })
+	return holds
+}
+
+// Approve runs a held operation. Review decisions are skipped this time, but Deny rules and
+// the balance are checked again; if they fail the hold is dropped and the error returned.
+func (m *Manager) Approve(holdID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h, ok := m.holds[holdID]
+	if !ok {
+		return ErrHoldNotFound
+	}
+	delete(m.holds, holdID)
+
+	if h.To == 0 {
+		return m.withdraw(h.From, h.Amount, true)
+	}
+	return m.transfer(h.From, h.To, h.Amount, true)
+}
+
+// Reject drops a held operation without running it.
+func (m *Manager) Reject(holdID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.holds[holdID]; !ok {
+		return ErrHoldNotFound
+	}
+	delete(m.holds, holdID)
+	return nil
+}
+
+// record appends a transaction for m.accounts[i]; the caller must hold m.mu.
+func (m *Manager) record(i int, kind string, amount float64, counterparty int) {
+	a := m.accounts[i]
+	m.transactions[a.ID] = append(m.transactions[a.ID], Transaction{
+		Kind:         kind,
+		Amount:       amount,
+		Balance:      a.Balance,
+		Counterparty: counterparty,
+		Time:         m.clock.Now(),
+	})
+}
+
+// Statement returns the account and its transactions, oldest first.
+func (m *Manager) Statement(id int) (Account, []Transaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, a := range m.accounts {
+		if a.ID == id {
+			return a, append([]Transaction(nil), m.transactions[id]...), nil
+		}
+	}
+	return Account{}, nil, ErrAccountNotFound
 }
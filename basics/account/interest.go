@@ -0,0 +1,132 @@
+// Interest accrues daily and is posted monthly through Manager.Deposit.
+// Simple interest is earned on the principal only (posted interest doesn't earn interest),
+// compound interest is earned on the balance plus the interest accrued so far.
+//
+// Accrue is idempotent for a given clock time: it catches up on every whole day
+// since the last call, so it can run from a scheduler or be called by hand.
+
+package account
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+type InterestKind int
+
+const (
+	SimpleInterest InterestKind = iota
+	CompoundInterest
+)
+
+var ErrInvalidRate = errors.New("interest rate must not be negative")
+
+type InterestPlan struct {
+	AnnualRate float64 // 0.05 means 5% per year
+	Kind       InterestKind
+}
+
+type accrual struct {
+	plan    InterestPlan
+	accrued float64   // accrued but not yet posted
+	posted  float64   // total interest posted so far
+	last    time.Time // start of the last accrued day
+}
+
+type InterestEngine struct {
+	mu      sync.Mutex
+	m       *Manager
+	clock   Clock
+	accrual map[int]*accrual
+}
+
+func NewInterestEngine(m *Manager, clock Clock) *InterestEngine {
+	return &InterestEngine{
+		m:       m,
+		clock:   clock,
+		accrual: map[int]*accrual{},
+	}
+}
+
+// SetPlan puts an account on an interest plan, starting from today.
+// Changing the plan of an account keeps the interest accrued under the old one.
+func (e *InterestEngine) SetPlan(id int, plan InterestPlan) error {
+	if plan.AnnualRate < 0 {
+		return ErrInvalidRate
+	}
+	if _, err := e.m.GetBalance(id); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if a, ok := e.accrual[id]; ok {
+		a.plan = plan
+		return nil
+	}
+	e.accrual[id] = &accrual{plan: plan, last: startOfDay(e.clock.Now())}
+	return nil
+}
+
+// Accrue adds one day of interest for every whole day elapsed since the last accrual,
+// and posts the accrued amount whenever a month boundary is crossed.
+func (e *InterestEngine) Accrue() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := e.clock.Now()
+	for id, a := range e.accrual {
+		for next := a.last.AddDate(0, 0, 1); !next.After(now); next = a.last.AddDate(0, 0, 1) {
+			balance, err := e.m.GetBalance(id)
+			if err != nil {
+				return err
+			}
+
+			base := balance - a.posted
+			if a.plan.Kind == CompoundInterest {
+				base = balance + a.accrued
+			}
+			a.accrued += math.Max(base, 0) * a.plan.AnnualRate / 365
+			a.last = next
+
+			if next.Day() == 1 {
+				if err := e.post(id, a); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// post deposits the accrued interest rounded to cents; the remainder keeps accruing.
+func (e *InterestEngine) post(id int, a *accrual) error {
+	amount := math.Floor(a.accrued*100) / 100
+	if amount <= 0 {
+		return nil
+	}
+	if err := e.m.Deposit(id, amount); err != nil {
+		return err
+	}
+	a.accrued -= amount
+	a.posted += amount
+	return nil
+}
+
+// Accrued returns the interest accrued but not yet posted.
+func (e *InterestEngine) Accrued(id int) float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if a, ok := e.accrual[id]; ok {
+		return a.accrued
+	}
+	return 0
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
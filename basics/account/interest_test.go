@@ -0,0 +1,139 @@
+package account
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+// 36500 at 10% a year earns exactly 10 a day, which keeps the arithmetic readable.
+const (
+	principal = 36500.0
+	dailyRate = 0.10 / 365
+)
+
+var jan15 = time.Date(2024, time.January, 15, 9, 30, 0, 0, time.UTC)
+
+func newInterestAccount(t *testing.T, kind InterestKind) (*Manager, *InterestEngine, *FakeClock, int) {
+	t.Helper()
+	clock := NewFakeClock(jan15)
+	m := NewManager()
+	m.SetClock(clock)
+	a := m.OpenAccount("Saver")
+	m.Deposit(a.ID, principal)
+
+	e := NewInterestEngine(m, clock)
+	if err := e.SetPlan(a.ID, InterestPlan{AnnualRate: 0.10, Kind: kind}); err != nil {
+		t.Fatal(err)
+	}
+	return m, e, clock, a.ID
+}
+
+func near(got, want float64) bool {
+	return math.Abs(got-want) < 1e-6
+}
+
+func TestSimpleInterestAccruesDaily(t *testing.T) {
+	m, e, clock, id := newInterestAccount(t, SimpleInterest)
+
+	clock.Advance(23 * time.Hour) // 08:30 the next day, not a whole day since midnight of the 15th
+	e.Accrue()
+	if got := e.Accrued(id); !near(got, 10) {
+		t.Fatalf("accrued after crossing midnight = %v, want 10", got)
+	}
+
+	clock.Advance(9 * 24 * time.Hour) // Jan 25
+	e.Accrue()
+	e.Accrue() // a second call at the same time adds nothing
+	if got := e.Accrued(id); !near(got, 100) {
+		t.Errorf("accrued on Jan 25 = %v, want 100", got)
+	}
+	if balance, _ := m.GetBalance(id); balance != principal {
+		t.Errorf("balance before the month ends = %v, want %v", balance, principal)
+	}
+}
+
+func TestSimpleInterestPostsMonthly(t *testing.T) {
+	m, e, clock, id := newInterestAccount(t, SimpleInterest)
+
+	clock.Advance(17 * 24 * time.Hour) // Feb 1: Jan 16 .. Feb 1 is 17 days
+	e.Accrue()
+	if balance, _ := m.GetBalance(id); !near(balance, principal+170) {
+		t.Fatalf("balance on Feb 1 = %v, want %v", balance, principal+170)
+	}
+	if got := e.Accrued(id); !near(got, 0) {
+		t.Errorf("accrued after posting = %v, want 0", got)
+	}
+
+	// posted interest doesn't earn interest
+	clock.Advance(5 * 24 * time.Hour)
+	e.Accrue()
+	if got := e.Accrued(id); !near(got, 50) {
+		t.Errorf("accrued five days after posting = %v, want 50", got)
+	}
+}
+
+func TestCompoundInterestAcrossMonths(t *testing.T) {
+	m, e, clock, id := newInterestAccount(t, CompoundInterest)
+
+	clock.Advance(24 * time.Hour)
+	e.Accrue()
+	clock.Advance(24 * time.Hour)
+	e.Accrue()
+	if got, want := e.Accrued(id), 10+(principal+10)*dailyRate; !near(got, want) {
+		t.Fatalf("accrued after two days = %v, want %v", got, want)
+	}
+
+	// Jan 15 to Mar 15 is 60 days and two postings; posting only moves interest from
+	// accrued to the balance, so the total compounds as if nothing was posted
+	clock.Advance(58 * 24 * time.Hour)
+	e.Accrue()
+	balance, _ := m.GetBalance(id)
+	if got, want := balance+e.Accrued(id), principal*math.Pow(1+dailyRate, 60); !near(got, want) {
+		t.Errorf("balance + accrued on Mar 15 = %v, want %v", got, want)
+	}
+	// simple interest would have posted 46 days of 10 by Mar 1
+	if balance <= principal+460 || balance > principal+470 {
+		t.Errorf("balance on Mar 15 = %v, want a little over %v", balance, principal+460)
+	}
+	if cents := balance * 100; !near(cents, math.Round(cents)) {
+		t.Errorf("posted balance %v isn't whole cents", balance)
+	}
+}
+
+func TestCompoundBeatsSimple(t *testing.T) {
+	ms, es, clocks, ids := newInterestAccount(t, SimpleInterest)
+	mc, ec, clockc, idc := newInterestAccount(t, CompoundInterest)
+	for range 365 {
+		clocks.Advance(24 * time.Hour)
+		clockc.Advance(24 * time.Hour)
+	}
+	es.Accrue()
+	ec.Accrue()
+
+	simple, _ := ms.GetBalance(ids)
+	compound, _ := mc.GetBalance(idc)
+	if !near(simple+es.Accrued(ids), principal+3650) {
+		t.Errorf("simple interest over a year = %v, want 3650", simple+es.Accrued(ids)-principal)
+	}
+	if compound <= simple {
+		t.Errorf("compound balance %v, simple %v; want compound ahead", compound, simple)
+	}
+}
+
+func TestSetPlan(t *testing.T) {
+	m := NewManager()
+	e := NewInterestEngine(m, NewFakeClock(jan15))
+	a := m.OpenAccount("A")
+
+	if err := e.SetPlan(a.ID, InterestPlan{AnnualRate: -0.01}); !errors.Is(err, ErrInvalidRate) {
+		t.Errorf("negative rate = %v, want ErrInvalidRate", err)
+	}
+	if err := e.SetPlan(a.ID+1, InterestPlan{AnnualRate: 0.01}); err == nil {
+		t.Error("SetPlan of a missing account succeeded")
+	}
+	if got := e.Accrued(a.ID); got != 0 {
+		t.Errorf("accrued without a plan = %v, want 0", got)
+	}
+}
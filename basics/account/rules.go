@@ -0,0 +1,271 @@
+// Fraud and velocity rules run before money leaves an account: Manager.WithDraw, and
+// Manager.Transfer on its source account. Each rule looks at the withdrawal and returns a decision:
+// Allow  - nothing to see
+// Review - hold the operation; it runs only once Manager.Approve is called with its hold ID
+// Deny   - block it; the operation returns a *RuleError carrying the rule name
+//
+// Rules run in order and the first Deny stops the pipeline. Every decision,
+// including Allow, is kept in the engine's decision log.
+
+package account
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type Decision int
+
+const (
+	Allow Decision = iota
+	Review
+	Deny
+)
+
+func (d Decision) String() string {
+	switch d {
+	case Allow:
+		return "allow"
+	case Review:
+		return "review"
+	case Deny:
+		return "deny"
+	default:
+		return fmt.Sprintf("Decision(%d)", int(d))
+	}
+}
+
+var (
+	ErrRuleDenied    = errors.New("operation denied by rule")
+	ErrHeldForReview = errors.New("operation held for review")
+)
+
+// RuleError is returned when a rule denies a withdrawal or transfer or holds it for review.
+type RuleError struct {
+	Op       string // "withdrawal" or "transfer"
+	Rule     string
+	Reason   string
+	Decision Decision // Deny or Review
+	HoldID   int      // for Review: the ID to pass to Manager.Approve or Manager.Reject
+}
+
+func (e *RuleError) Error() string {
+	if e.Decision == Review {
+		return fmt.Sprintf("%s held for review (hold %d) by rule %s: %s", e.Op, e.HoldID, e.Rule, e.Reason)
+	}
+	return fmt.Sprintf("%s denied by rule %s: %s", e.Op, e.Rule, e.Reason)
+}
+
+func (e *RuleError) Is(target error) bool {
+	if e.Decision == Review {
+		return target == ErrHeldForReview
+	}
+	return target == ErrRuleDenied
+}
+
+type Withdrawal struct {
+	AccountID int
+	Amount    float64
+	Time      time.Time
+}
+
+type Rule interface {
+	Name() string
+	Evaluate(w Withdrawal) (Decision, string)
+}
+
+// Observer is implemented by rules that need to see executed withdrawals, like velocity limits.
+type Observer interface {
+	Observe(w Withdrawal)
+}
+
+type DecisionRecord struct {
+	Withdrawal
+	Rule     string
+	Decision Decision
+	Reason   string
+}
+
+type RuleEngine struct {
+	mu        sync.Mutex
+	clock     Clock
+	rules     []Rule
+	decisions []DecisionRecord
+}
+
+func NewRuleEngine(clock Clock, rules ...Rule) *RuleEngine {
+	return &RuleEngine{clock: clock, rules: rules}
+}
+
+// check runs every rule against op's withdrawal and logs each decision.
+// A Deny wins over a Review. approved is for a hold being released: its Review decisions
+// were logged when it was held, so they are neither logged again nor acted on.
+func (e *RuleEngine) check(op string, accountID int, amount float64, approved bool) (Withdrawal, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	w := Withdrawal{AccountID: accountID, Amount: amount, Time: e.clock.Now()}
+	var review *RuleError
+	for _, r := range e.rules {
+		decision, reason := r.Evaluate(w)
+		if decision == Review && approved {
+			continue
+		}
+		e.decisions = append(e.decisions, DecisionRecord{Withdrawal: w, Rule: r.Name(), Decision: decision, Reason: reason})
+
+		switch {
+		case decision == Deny:
+			return w, &RuleError{Op: op, Rule: r.Name(), Reason: reason, Decision: Deny}
+		case decision == Review && review == nil:
+			review = &RuleError{Op: op, Rule: r.Name(), Reason: reason, Decision: Review}
+		}
+	}
+	if review != nil {
+		return w, review
+	}
+	return w, nil
+}
+
+// observe tells stateful rules that the withdrawal went through.
+func (e *RuleEngine) observe(w Withdrawal) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, r := range e.rules {
+		if o, ok := r.(Observer); ok {
+			o.Observe(w)
+		}
+	}
+}
+
+// Decisions returns the decision log, oldest first.
+func (e *RuleEngine) Decisions() []DecisionRecord {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return append([]DecisionRecord(nil), e.decisions...)
+}
+
+// Flagged returns the decisions that need a review.
+func (e *RuleEngine) Flagged() []DecisionRecord {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var flagged []DecisionRecord
+	for _, d := range e.decisions {
+		if d.Decision == Review {
+			flagged = append(flagged, d)
+		}
+	}
+	return flagged
+}
+
+// VelocityRule limits the number and/or total amount of withdrawals per account
+// in a rolling window. A zero limit is not enforced.
+type VelocityRule struct {
+	MaxCount  int
+	MaxAmount float64
+	Window    time.Duration
+
+	history map[int][]Withdrawal
+}
+
+func (r *VelocityRule) Name() string {
+	return "velocity"
+}
+
+func (r *VelocityRule) Evaluate(w Withdrawal) (Decision, string) {
+	recent := r.recent(w.AccountID, w.Time)
+
+	if r.MaxCount > 0 && len(recent)+1 > r.MaxCount {
+		return Deny, fmt.Sprintf("more than %d withdrawals in %v", r.MaxCount, r.Window)
+	}
+
+	total := w.Amount
+	for _, past := range recent {
+		total += past.Amount
+	}
+	if r.MaxAmount > 0 && total > r.MaxAmount {
+		return Deny, fmt.Sprintf("$%.2f withdrawn in %v exceeds $%.2f", total, r.Window, r.MaxAmount)
+	}
+
+	return Allow, ""
+}
+
+func (r *VelocityRule) Observe(w Withdrawal) {
+	if r.history == nil {
+		r.history = map[int][]Withdrawal{}
+	}
+	r.history[w.AccountID] = append(r.recent(w.AccountID, w.Time), w)
+}
+
+// recent drops withdrawals that fell out of the window and returns the rest.
+func (r *VelocityRule) recent(id int, now time.Time) []Withdrawal {
+	cutoff := now.Add(-r.Window)
+	kept := r.history[id][:0]
+	for _, past := range r.history[id] {
+		if past.Time.After(cutoff) {
+			kept = append(kept, past)
+		}
+	}
+	if r.history != nil {
+		r.history[id] = kept
+	}
+	return kept
+}
+
+// LargeTransactionRule flags withdrawals at or above Threshold for review.
+type LargeTransactionRule struct {
+	Threshold float64
+}
+
+func (r *LargeTransactionRule) Name() string {
+	return "large-transaction"
+}
+
+func (r *LargeTransactionRule) Evaluate(w Withdrawal) (Decision, string) {
+	if w.Amount >= r.Threshold {
+		return Review, fmt.Sprintf("$%.2f is at or above $%.2f", w.Amount, r.Threshold)
+	}
+	return Allow, ""
+}
+
+// BlocklistRule denies every withdrawal from a blocked account.
+type BlocklistRule struct {
+	mu      sync.Mutex
+	blocked map[int]string // account ID -> reason
+}
+
+func NewBlocklistRule() *BlocklistRule {
+	return &BlocklistRule{blocked: map[int]string{}}
+}
+
+func (r *BlocklistRule) Block(id int, reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.blocked[id] = reason
+}
+
+func (r *BlocklistRule) Unblock(id int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.blocked, id)
+}
+
+func (r *BlocklistRule) Name() string {
+	return "blocklist"
+}
+
+func (r *BlocklistRule) Evaluate(w Withdrawal) (Decision, string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if reason, ok := r.blocked[w.AccountID]; ok {
+		return Deny, "account is blocked: " + reason
+	}
+	return Allow, ""
+}
@@ -0,0 +1,119 @@
+package account
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTransferRunsRules(t *testing.T) {
+	m := NewManager()
+	a, b := m.OpenAccount("A"), m.OpenAccount("B")
+	m.Deposit(a.ID, 1000)
+
+	blocklist := NewBlocklistRule()
+	m.SetRules(NewRuleEngine(RealClock(), blocklist, &VelocityRule{MaxCount: 2, Window: time.Hour}))
+
+	blocklist.Block(a.ID, "stolen card")
+	if err := m.Transfer(a.ID, b.ID, 100); !errors.Is(err, ErrRuleDenied) {
+		t.Fatalf("transfer from a blocked account = %v, want ErrRuleDenied", err)
+	}
+	blocklist.Unblock(a.ID)
+
+	m.Transfer(a.ID, b.ID, 1)
+	m.WithDraw(a.ID, 1)
+	if err := m.Transfer(a.ID, b.ID, 1); !errors.Is(err, ErrRuleDenied) {
+		t.Errorf("third transfer in the window = %v, want ErrRuleDenied", err)
+	}
+}
+
+func TestReviewHoldsTheOperation(t *testing.T) {
+	m := NewManager()
+	a, b := m.OpenAccount("A"), m.OpenAccount("B")
+	m.Deposit(a.ID, 1000)
+	m.SetRules(NewRuleEngine(RealClock(), &LargeTransactionRule{Threshold: 500}))
+
+	err := m.Transfer(a.ID, b.ID, 600)
+	var ruleErr *RuleError
+	if !errors.As(err, &ruleErr) || !errors.Is(err, ErrHeldForReview) {
+		t.Fatalf("large transfer = %v, want ErrHeldForReview", err)
+	}
+	if balance, _ := m.GetBalance(a.ID); balance != 1000 {
+		t.Fatalf("held transfer moved money: balance %v", balance)
+	}
+	if holds := m.Holds(); len(holds) != 1 || holds[0].ID != ruleErr.HoldID || holds[0].To != b.ID {
+		t.Fatalf("Holds() = %+v", holds)
+	}
+
+	if err := m.Approve(ruleErr.HoldID); err != nil {
+		t.Fatalf("Approve: %v", err)
+	}
+	if balance, _ := m.GetBalance(b.ID); balance != 600 {
+		t.Errorf("balance after approval = %v, want 600", balance)
+	}
+	if err := m.Approve(ruleErr.HoldID); !errors.Is(err, ErrHoldNotFound) {
+		t.Errorf("second Approve = %v, want ErrHoldNotFound", err)
+	}
+
+	m.WithDraw(a.ID, 500)
+	held := m.Holds()[0]
+	if err := m.Reject(held.ID); err != nil {
+		t.Fatalf("Reject: %v", err)
+	}
+	if balance, _ := m.GetBalance(a.ID); balance != 400 || len(m.Holds()) != 0 {
+		t.Errorf("after Reject: balance %v, holds %v", balance, m.Holds())
+	}
+}
+
+func TestApprovedHoldIsFlaggedOnce(t *testing.T) {
+	m := NewManager()
+	a := m.OpenAccount("A")
+	m.Deposit(a.ID, 1000)
+	engine := NewRuleEngine(RealClock(), &LargeTransactionRule{Threshold: 500}, &VelocityRule{MaxCount: 5, Window: time.Hour})
+	m.SetRules(engine)
+
+	var ruleErr *RuleError
+	if err := m.WithDraw(a.ID, 550); !errors.As(err, &ruleErr) {
+		t.Fatalf("large withdrawal = %v, want a *RuleError", err)
+	}
+	if err := m.Approve(ruleErr.HoldID); err != nil {
+		t.Fatalf("Approve: %v", err)
+	}
+
+	if flagged := engine.Flagged(); len(flagged) != 1 || flagged[0].Amount != 550 {
+		t.Errorf("Flagged() after approval = %+v, want the withdrawal once", flagged)
+	}
+	// the velocity rule's Allow is logged on both passes
+	if got := len(engine.Decisions()); got != 3 {
+		t.Errorf("%d decisions logged, want 3", got)
+	}
+}
+
+func TestRuleErrorNamesTheOperation(t *testing.T) {
+	m := NewManager()
+	a, b := m.OpenAccount("A"), m.OpenAccount("B")
+	m.Deposit(a.ID, 1000)
+	blocklist := NewBlocklistRule()
+	m.SetRules(NewRuleEngine(RealClock(), blocklist, &LargeTransactionRule{Threshold: 500}))
+
+	tests := []struct {
+		name string
+		run  func() error
+		want string
+	}{
+		{"held transfer", func() error { return m.Transfer(a.ID, b.ID, 600) }, "transfer held for review (hold 1) by rule large-transaction: $600.00 is at or above $500.00"},
+		{"held withdrawal", func() error { return m.WithDraw(a.ID, 600) }, "withdrawal held for review (hold 2) by rule large-transaction: $600.00 is at or above $500.00"},
+		{"denied transfer", func() error {
+			blocklist.Block(a.ID, "stolen card")
+			defer blocklist.Unblock(a.ID)
+			return m.Transfer(a.ID, b.ID, 10)
+		}, "transfer denied by rule blocklist: account is blocked: stolen card"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.run(); err == nil || err.Error() != tt.want {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
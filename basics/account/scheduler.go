@@ -0,0 +1,79 @@
+// Scheduler runs recurring account jobs (interest accrual, standing transfers)
+// as cancellable tasks, in the same way as concurrency.TaskManager.
+// The APIs contains:
+// NewScheduler(Clock) - constructor
+// Every(string, time.Duration, Job) - run a job on a fixed interval
+// ScheduleTransfer(...) - recurring transfer between two accounts
+// Stop() - cancel all jobs
+// Wait() - wait all jobs exit
+
+package account
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type Job func(ctx context.Context) error
+
+type Scheduler struct {
+	clock  Clock
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewScheduler(clock Clock) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		clock:  clock,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Every runs job each interval until the scheduler is stopped.
+// A failing run is reported and the job keeps its schedule.
+func (s *Scheduler) Every(name string, interval time.Duration, job Job) {
+	s.wg.Add(1)
+
+	go func() {
+		defer s.wg.Done()
+
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-s.clock.After(interval):
+				if err := job(s.ctx); err != nil {
+					fmt.Printf("[%s] failed: %v\n", name, err)
+				}
+			}
+		}
+	}()
+}
+
+// ScheduleTransfer moves amount from one account to another each interval.
+func (s *Scheduler) ScheduleTransfer(m *Manager, from, to int, amount float64, interval time.Duration) {
+	name := fmt.Sprintf("transfer %d->%d", from, to)
+	s.Every(name, interval, func(ctx context.Context) error {
+		return m.Transfer(from, to, amount)
+	})
+}
+
+// ScheduleInterest accrues interest once a day.
+func (s *Scheduler) ScheduleInterest(e *InterestEngine) {
+	s.Every("interest", 24*time.Hour, func(ctx context.Context) error {
+		return e.Accrue()
+	})
+}
+
+func (s *Scheduler) Stop() {
+	s.cancel()
+}
+
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
@@ -0,0 +1,134 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEveryFiresOnTheInterval(t *testing.T) {
+	clock := NewFakeClock(jan15)
+	s := NewScheduler(clock)
+	defer func() {
+		s.Stop()
+		s.Wait()
+	}()
+
+	runs := make(chan time.Time, 10)
+	s.Every("tick", time.Hour, func(context.Context) error {
+		runs <- clock.Now()
+		return nil
+	})
+
+	clock.BlockUntil(1)
+	clock.Advance(59 * time.Minute)
+	select {
+	case at := <-runs:
+		t.Fatalf("job ran at %v, before its interval", at)
+	default:
+	}
+
+	clock.Advance(time.Minute)
+	if at := <-runs; !at.Equal(jan15.Add(time.Hour)) {
+		t.Errorf("first run at %v, want %v", at, jan15.Add(time.Hour))
+	}
+
+	// the next interval starts when the job is done waiting again
+	clock.BlockUntil(1)
+	clock.Advance(time.Hour)
+	if at := <-runs; !at.Equal(jan15.Add(2 * time.Hour)) {
+		t.Errorf("second run at %v, want %v", at, jan15.Add(2*time.Hour))
+	}
+}
+
+func TestEveryKeepsItsScheduleAfterAFailure(t *testing.T) {
+	clock := NewFakeClock(jan15)
+	s := NewScheduler(clock)
+	defer func() {
+		s.Stop()
+		s.Wait()
+	}()
+
+	runs := make(chan int, 10)
+	n := 0
+	s.Every("flaky", time.Minute, func(context.Context) error {
+		n++
+		runs <- n
+		if n == 1 {
+			return errors.New("first run fails")
+		}
+		return nil
+	})
+
+	for want := 1; want <= 2; want++ {
+		clock.BlockUntil(1)
+		clock.Advance(time.Minute)
+		if got := <-runs; got != want {
+			t.Fatalf("run %d, want %d", got, want)
+		}
+	}
+}
+
+func TestStopEndsEveryJob(t *testing.T) {
+	clock := NewFakeClock(jan15)
+	s := NewScheduler(clock)
+	ran := make(chan struct{}, 10)
+	for range 3 {
+		s.Every("job", time.Hour, func(context.Context) error {
+			ran <- struct{}{}
+			return nil
+		})
+	}
+
+	clock.BlockUntil(3)
+	s.Stop()
+	s.Wait()
+	clock.Advance(time.Hour) // nobody is left to receive it
+	if len(ran) != 0 {
+		t.Errorf("%d jobs ran after Stop", len(ran))
+	}
+}
+
+func TestScheduleTransfer(t *testing.T) {
+	clock := NewFakeClock(jan15)
+	m := NewManager()
+	a, b := m.OpenAccount("A"), m.OpenAccount("B")
+	m.Deposit(a.ID, 25)
+
+	s := NewScheduler(clock)
+	s.ScheduleTransfer(m, a.ID, b.ID, 10, 24*time.Hour)
+	for range 3 {
+		clock.BlockUntil(1)
+		clock.Advance(24 * time.Hour)
+	}
+	clock.BlockUntil(1) // the third run is done
+	s.Stop()
+	s.Wait()
+
+	// the third transfer found only 5 left and failed
+	if balance, _ := m.GetBalance(b.ID); balance != 20 {
+		t.Errorf("B after three days = %v, want 20", balance)
+	}
+	if balance, _ := m.GetBalance(a.ID); balance != 5 {
+		t.Errorf("A after three days = %v, want 5", balance)
+	}
+}
+
+func TestScheduleInterest(t *testing.T) {
+	m, e, clock, id := newInterestAccount(t, SimpleInterest)
+	s := NewScheduler(clock)
+	s.ScheduleInterest(e)
+
+	for range 17 { // to Feb 1, one day at a time
+		clock.BlockUntil(1)
+		clock.Advance(24 * time.Hour)
+	}
+	clock.BlockUntil(1)
+	s.Stop()
+	s.Wait()
+
+	if balance, _ := m.GetBalance(id); !near(balance, principal+170) {
+		t.Errorf("balance on Feb 1 = %v, want %v", balance, principal+170)
+	}
+}
@@ -0,0 +1,389 @@
+// Server exposes a Manager as an HTTP/JSON banking API.
+//
+// POST /accounts                      {"name"}                 open an account
+// GET  /accounts/{id}/balance                                  get balance
+// POST /accounts/{id}/deposit         {"amount"}               deposit
+// POST /accounts/{id}/withdraw        {"amount"}               withdraw
+// POST /transfers                     {"from","to","amount"}   transfer
+// GET  /accounts/{id}/statement                                statement
+//
+// Errors are returned as {"error": {"code", "message"}} with a status mapped from the manager's errors.
+// Mutating requests may carry an Idempotency-Key header: a retry with the same key gets the
+// first response back instead of applying the operation twice. Reusing a key with a different
+// body is rejected with 422; 5xx responses aren't kept, so those can be retried. Keys expire
+// after idempotencyTTL and at most idempotencyMaxKeys are remembered.
+
+package account
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type OpenAccountRequest struct {
+	Name string `json:"name"`
+}
+
+type AmountRequest struct {
+	Amount float64 `json:"amount"`
+}
+
+type TransferRequest struct {
+	From   int     `json:"from"`
+	To     int     `json:"to"`
+	Amount float64 `json:"amount"`
+}
+
+type AccountResponse struct {
+	ID      int     `json:"id"`
+	Name    string  `json:"name,omitempty"`
+	Balance float64 `json:"balance"`
+}
+
+type TransferResponse struct {
+	From AccountResponse `json:"from"`
+	To   AccountResponse `json:"to"`
+}
+
+type StatementResponse struct {
+	AccountResponse
+	Transactions []Transaction `json:"transactions"`
+}
+
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+type ErrorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+var (
+	// errValidation marks errors caused by a malformed request.
+	errValidation = errors.New("invalid request")
+	// errKeyReused is a retry whose body differs from the request that first used the key.
+	errKeyReused = errors.New("idempotency key reused with a different request body")
+)
+
+const (
+	idempotencyTTL      = 24 * time.Hour
+	idempotencyMaxKeys  = 10000
+	maxRequestBodyBytes = 1 << 20
+)
+
+type Server struct {
+	m   *Manager
+	mux *http.ServeMux
+
+	mu          sync.Mutex
+	idempotency map[string]*cachedResponse
+	keyOrder    []*cachedResponse // oldest first, for expiry and the size cap
+}
+
+// cachedResponse is filled in once; ready is closed when it is, so concurrent
+// retries with the same key wait for the first request instead of racing it.
+type cachedResponse struct {
+	key      string
+	bodyHash [sha256.Size]byte
+	expires  time.Time
+	ready    chan struct{}
+	status   int
+	body     []byte
+}
+
+func NewServer(m *Manager) *Server {
+	s := &Server{
+		m:           m,
+		mux:         http.NewServeMux(),
+		idempotency: map[string]*cachedResponse{},
+	}
+
+	s.mux.HandleFunc("POST /accounts", s.idempotent(s.openAccount))
+	s.mux.HandleFunc("GET /accounts/{id}/balance", s.handle(s.balance))
+	s.mux.HandleFunc("POST /accounts/{id}/deposit", s.idempotent(s.deposit))
+	s.mux.HandleFunc("POST /accounts/{id}/withdraw", s.idempotent(s.withdraw))
+	s.mux.HandleFunc("POST /transfers", s.idempotent(s.transfer))
+	s.mux.HandleFunc("GET /accounts/{id}/statement", s.handle(s.statement))
+
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// apiFunc returns the status and value to encode, or an error to map.
+type apiFunc func(r *http.Request) (int, any, error)
+
+func (s *Server) handle(fn apiFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status, body := encode(fn(r))
+		writeJSON(w, status, body)
+	}
+}
+
+func (s *Server) idempotent(fn apiFunc) http.HandlerFunc {
+	plain := s.handle(fn)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" {
+			plain(w, r)
+			return
+		}
+		key = r.Method + " " + r.URL.Path + " " + key // a key is scoped to its endpoint
+
+		payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBodyBytes))
+		if err != nil {
+			status, body := encode(0, nil, fmt.Errorf("%w: %v", errValidation, err))
+			writeJSON(w, status, body)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(payload))
+		hash := sha256.Sum256(payload)
+
+		now := time.Now()
+		s.mu.Lock()
+		s.expireKeys(now)
+		cached, seen := s.idempotency[key]
+		if !seen {
+			cached = &cachedResponse{key: key, bodyHash: hash, expires: now.Add(idempotencyTTL), ready: make(chan struct{})}
+			s.idempotency[key] = cached
+			s.keyOrder = append(s.keyOrder, cached)
+		}
+		s.mu.Unlock()
+
+		if seen && cached.bodyHash != hash {
+			status, body := encode(0, nil, errKeyReused)
+			writeJSON(w, status, body)
+			return
+		}
+
+		if !seen {
+			s.fill(cached, fn, r)
+		}
+		<-cached.ready
+
+		if seen {
+			w.Header().Set("Idempotent-Replayed", "true")
+		}
+		writeJSON(w, cached.status, cached.body)
+	}
+}
+
+// expireKeys drops expired keys and the oldest ones beyond idempotencyMaxKeys; s.mu must be held.
+// A request still in flight keeps its entry through the pointer it holds.
+func (s *Server) expireKeys(now time.Time) {
+	for len(s.keyOrder) > 0 {
+		oldest := s.keyOrder[0]
+		if s.idempotency[oldest.key] == oldest && now.Before(oldest.expires) && len(s.idempotency) < idempotencyMaxKeys {
+			break
+		}
+		if s.idempotency[oldest.key] == oldest {
+			delete(s.idempotency, oldest.key)
+		}
+		s.keyOrder[0] = nil
+		s.keyOrder = s.keyOrder[1:]
+	}
+}
+
+// fill runs fn and keeps its response in c. ready is closed even if fn panics, so the
+// requests waiting on c get a 500 instead of hanging, and the key can be retried.
+func (s *Server) fill(c *cachedResponse, fn apiFunc, r *http.Request) {
+	c.status, c.body = encode(0, nil, errors.New("request did not complete"))
+	defer func() {
+		if c.status >= http.StatusInternalServerError {
+			s.forgetKey(c) // a server error is worth retrying
+		}
+		close(c.ready)
+	}()
+
+	c.status, c.body = encode(fn(r))
+}
+
+func (s *Server) forgetKey(c *cachedResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.idempotency[c.key] == c {
+		delete(s.idempotency, c.key)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+func encode(status int, v any, err error) (int, []byte) {
+	if err != nil {
+		status, v = errorResponse(err)
+	}
+
+	body, err := json.Marshal(v)
+	if err != nil {
+		status, v = errorResponse(err)
+		body, _ = json.Marshal(v)
+	}
+	return status, append(body, '\n')
+}
+
+func errorResponse(err error) (int, ErrorResponse) {
+	status, code := http.StatusInternalServerError, "internal"
+
+	switch {
+	case errors.Is(err, errKeyReused):
+		status, code = http.StatusUnprocessableEntity, "idempotency_key_reused"
+	case errors.Is(err, errValidation):
+		status, code = http.StatusBadRequest, "invalid_request"
+	case errors.Is(err, ErrSameAccount):
+		status, code = http.StatusBadRequest, "same_account"
+	case errors.Is(err, ErrAccountNotFound):
+		status, code = http.StatusNotFound, "account_not_found"
+	case errors.Is(err, ErrInsufficientFunds):
+		status, code = http.StatusUnprocessableEntity, "insufficient_funds"
+	case errors.Is(err, ErrRuleDenied):
+		status, code = http.StatusForbidden, "rule_denied"
+	case errors.Is(err, ErrHeldForReview):
+		status, code = http.StatusAccepted, "held_for_review"
+	}
+
+	return status, ErrorResponse{Error: ErrorBody{Code: code, Message: err.Error()}}
+}
+
+func decodeBody(r *http.Request, v any) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("%w: %v", errValidation, err)
+	}
+	return nil
+}
+
+func pathID(r *http.Request) (int, error) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("%w: account id must be a positive integer", errValidation)
+	}
+	return id, nil
+}
+
+func validAmount(amount float64) error {
+	if amount <= 0 {
+		return fmt.Errorf("%w: amount must be positive", errValidation)
+	}
+	return nil
+}
+
+func (s *Server) accountResponse(id int) (AccountResponse, error) {
+	balance, err := s.m.GetBalance(id)
+	if err != nil {
+		return AccountResponse{}, err
+	}
+	return AccountResponse{ID: id, Balance: balance}, nil
+}
+
+func (s *Server) openAccount(r *http.Request) (int, any, error) {
+	var req OpenAccountRequest
+	if err := decodeBody(r, &req); err != nil {
+		return 0, nil, err
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return 0, nil, fmt.Errorf("%w: name is required", errValidation)
+	}
+
+	a := s.m.OpenAccount(req.Name)
+	return http.StatusCreated, AccountResponse{ID: a.ID, Name: a.Name, Balance: a.Balance}, nil
+}
+
+func (s *Server) balance(r *http.Request) (int, any, error) {
+	id, err := pathID(r)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	resp, err := s.accountResponse(id)
+	return http.StatusOK, resp, err
+}
+
+func (s *Server) deposit(r *http.Request) (int, any, error) {
+	return s.amountOp(r, s.m.Deposit)
+}
+
+func (s *Server) withdraw(r *http.Request) (int, any, error) {
+	return s.amountOp(r, s.m.WithDraw)
+}
+
+func (s *Server) amountOp(r *http.Request, op func(id int, amount float64) error) (int, any, error) {
+	id, err := pathID(r)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var req AmountRequest
+	if err := decodeBody(r, &req); err != nil {
+		return 0, nil, err
+	}
+	if err := validAmount(req.Amount); err != nil {
+		return 0, nil, err
+	}
+
+	if err := op(id, req.Amount); err != nil {
+		return 0, nil, err
+	}
+
+	resp, err := s.accountResponse(id)
+	return http.StatusOK, resp, err
+}
+
+func (s *Server) transfer(r *http.Request) (int, any, error) {
+	var req TransferRequest
+	if err := decodeBody(r, &req); err != nil {
+		return 0, nil, err
+	}
+	if err := validAmount(req.Amount); err != nil {
+		return 0, nil, err
+	}
+
+	if err := s.m.Transfer(req.From, req.To, req.Amount); err != nil {
+		return 0, nil, err
+	}
+
+	from, err := s.accountResponse(req.From)
+	if err != nil {
+		return 0, nil, err
+	}
+	to, err := s.accountResponse(req.To)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, TransferResponse{From: from, To: to}, nil
+}
+
+func (s *Server) statement(r *http.Request) (int, any, error) {
+	id, err := pathID(r)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	a, txs, err := s.m.Statement(id)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, StatementResponse{
+		AccountResponse: AccountResponse{ID: a.ID, Name: a.Name, Balance: a.Balance},
+		Transactions:    txs,
+	}, nil
+}
@@ -0,0 +1,182 @@
+package account
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func call(t *testing.T, srv *httptest.Server, method, path, body, idempotencyKey string) (int, string) {
+	t.Helper()
+
+	req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	res, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	out, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res.StatusCode, string(out)
+}
+
+// TestAPI runs the API end to end; the cases share one server and run in order.
+func TestAPI(t *testing.T) {
+	srv := httptest.NewServer(NewServer(NewManager()))
+	defer srv.Close()
+
+	cases := []struct {
+		name           string
+		method, path   string
+		body           string
+		idempotencyKey string
+		wantStatus     int
+	}{
+		{"open alice", "POST", "/accounts", `{"name":"Alice"}`, "", http.StatusCreated},
+		{"open bob", "POST", "/accounts", `{"name":"Bob"}`, "", http.StatusCreated},
+		{"open without name", "POST", "/accounts", `{"name":"  "}`, "", http.StatusBadRequest},
+		{"unknown field", "POST", "/accounts", `{"nam":"typo"}`, "", http.StatusBadRequest},
+		{"deposit", "POST", "/accounts/1/deposit", `{"amount":100}`, "dep-1", http.StatusOK},
+		{"deposit retried", "POST", "/accounts/1/deposit", `{"amount":100}`, "dep-1", http.StatusOK},
+		{"negative deposit", "POST", "/accounts/1/deposit", `{"amount":-5}`, "", http.StatusBadRequest},
+		{"withdraw", "POST", "/accounts/1/withdraw", `{"amount":30}`, "", http.StatusOK},
+		{"overdraw", "POST", "/accounts/2/withdraw", `{"amount":30}`, "", http.StatusUnprocessableEntity},
+		{"transfer", "POST", "/transfers", `{"from":1,"to":2,"amount":20}`, "", http.StatusOK},
+		{"transfer to self", "POST", "/transfers", `{"from":1,"to":1,"amount":20}`, "", http.StatusBadRequest},
+		{"missing account", "GET", "/accounts/42/balance", "", "", http.StatusNotFound},
+		{"bad id", "GET", "/accounts/abc/balance", "", "", http.StatusBadRequest},
+		{"balance", "GET", "/accounts/1/balance", "", "", http.StatusOK},
+		{"statement", "GET", "/accounts/1/statement", "", "", http.StatusOK},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			status, body := call(t, srv, c.method, c.path, c.body, c.idempotencyKey)
+			if status != c.wantStatus {
+				t.Errorf("%s %s = %d %s, want %d", c.method, c.path, status, body, c.wantStatus)
+			}
+		})
+	}
+
+	t.Run("retried deposit applied once", func(t *testing.T) {
+		_, body := call(t, srv, "GET", "/accounts/1/balance", "", "")
+		var final AccountResponse
+		if err := json.Unmarshal([]byte(body), &final); err != nil {
+			t.Fatal(err)
+		}
+		if final.Balance != 50 { // 100 - 30 - 20
+			t.Errorf("balance = %v, want 50", final.Balance)
+		}
+	})
+}
+
+func TestIdempotencyKeyReusedWithDifferentBody(t *testing.T) {
+	srv := httptest.NewServer(NewServer(NewManager()))
+	defer srv.Close()
+
+	call(t, srv, "POST", "/accounts", `{"name":"Alice"}`, "")
+	if status, body := call(t, srv, "POST", "/accounts/1/deposit", `{"amount":100}`, "k"); status != http.StatusOK {
+		t.Fatalf("first deposit = %d %s", status, body)
+	}
+	status, body := call(t, srv, "POST", "/accounts/1/deposit", `{"amount":900}`, "k")
+	if status != http.StatusUnprocessableEntity || !strings.Contains(body, "idempotency_key_reused") {
+		t.Errorf("reused key = %d %s, want 422 idempotency_key_reused", status, body)
+	}
+
+	_, body = call(t, srv, "GET", "/accounts/1/balance", "", "")
+	var got AccountResponse
+	if err := json.Unmarshal([]byte(body), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Balance != 100 {
+		t.Errorf("balance = %v, want 100", got.Balance)
+	}
+}
+
+func TestIdempotencyKeysExpire(t *testing.T) {
+	s := NewServer(NewManager())
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range idempotencyMaxKeys + 5 {
+		c := &cachedResponse{key: strconv.Itoa(i), expires: now.Add(idempotencyTTL)}
+		s.idempotency[c.key] = c
+		s.keyOrder = append(s.keyOrder, c)
+	}
+
+	s.expireKeys(now)
+	if len(s.idempotency) >= idempotencyMaxKeys {
+		t.Errorf("%d keys kept, want fewer than %d", len(s.idempotency), idempotencyMaxKeys)
+	}
+	if _, ok := s.idempotency["0"]; ok {
+		t.Error("oldest key survived the size cap")
+	}
+
+	s.expireKeys(now.Add(idempotencyTTL))
+	if len(s.idempotency) != 0 {
+		t.Errorf("%d keys kept after the TTL, want 0", len(s.idempotency))
+	}
+}
+
+func TestIdempotentHandlerPanics(t *testing.T) {
+	s := NewServer(NewManager())
+	calls := 0
+	var first *cachedResponse
+	h := s.idempotent(func(r *http.Request) (int, any, error) {
+		calls++
+		if calls == 1 {
+			s.mu.Lock()
+			first = s.idempotency["POST /x k"]
+			s.mu.Unlock()
+			panic("handler bug")
+		}
+		return http.StatusOK, AccountResponse{ID: 1}, nil
+	})
+	post := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/x", strings.NewReader(`{}`))
+		req.Header.Set("Idempotency-Key", "k")
+		rec := httptest.NewRecorder()
+		h(rec, req)
+		return rec
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("the handler's panic was swallowed")
+			}
+		}()
+		post()
+	}()
+
+	select {
+	case <-first.ready:
+	default:
+		t.Fatal("ready is still open after the panic, so waiting retries would hang")
+	}
+	if first.status != http.StatusInternalServerError {
+		t.Errorf("waiting retries get %d, want 500", first.status)
+	}
+
+	// the key was forgotten, so a retry runs the handler instead of replaying a failure
+	rec := post()
+	if rec.Code != http.StatusOK || calls != 2 || rec.Header().Get("Idempotent-Replayed") != "" {
+		t.Errorf("retry = %d after %d calls, replayed %q; want 200 from a second call", rec.Code, calls, rec.Header().Get("Idempotent-Replayed"))
+	}
+}
@@ -0,0 +1,410 @@
+// Store makes a Manager durable across restarts.
+// Every committed operation is appended to a write-ahead log (WAL) and fsynced
+// before it is applied in memory, so an acknowledged operation is never lost.
+// Every snapshotEvery operations the whole state is written to a snapshot and the
+// log is truncated, which keeps recovery time bounded.
+//
+// On disk (inside dir):
+// snapshot.json - last snapshot: {seq, next_id, accounts}
+// wal.log       - one record per line: "<crc32 hex> <json>\n"
+//
+// Recovery = load snapshot + replay WAL records with seq > snapshot seq.
+// A crash in the middle of an append leaves a torn last record (no newline or bad checksum),
+// which is dropped and truncated away; a bad record followed by more data is real corruption.
+
+package account
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	snapshotFile = "snapshot.json"
+	walFile      = "wal.log"
+)
+
+var (
+	ErrCorruptLog  = errors.New("write-ahead log is corrupt")
+	ErrStoreFailed = errors.New("store failed; reopen it to recover")
+)
+
+const (
+	opOpen     = "open"
+	opDeposit  = "deposit"
+	opWithdraw = "withdraw"
+)
+
+type walRecord struct {
+	Seq    uint64  `json:"seq"`
+	Op     string  `json:"op"`
+	ID     int     `json:"id"`
+	Name   string  `json:"name,omitempty"`
+	Amount float64 `json:"amount,omitempty"`
+}
+
+type snapshot struct {
+	Seq      uint64    `json:"seq"`
+	NextID   int       `json:"next_id"`
+	Accounts []Account `json:"accounts"`
+}
+
+// logFile is the part of *os.File the store writes the WAL through; tests swap in one that fails.
+type logFile interface {
+	io.ReadWriteSeeker
+	Truncate(size int64) error
+	Sync() error
+	Close() error
+}
+
+type Store struct {
+	mu            sync.Mutex
+	dir           string
+	m             *Manager
+	wal           logFile
+	seq           uint64 // sequence number of the last committed record
+	failed        error  // set when a failed append couldn't be rolled back
+	snapshotErr   error  // why the last automatic snapshot failed, if it did
+	snapshotEvery int
+	sinceSnapshot int
+}
+
+// OpenStore recovers the state kept in dir (creating it if needed) and
+// takes a snapshot every snapshotEvery committed operations (0 disables it).
+func OpenStore(dir string, snapshotEvery int) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	s := &Store{
+		dir:           dir,
+		m:             NewManager(),
+		snapshotEvery: snapshotEvery,
+	}
+
+	if err := s.loadSnapshot(); err != nil {
+		return nil, err
+	}
+
+	wal, err := os.OpenFile(filepath.Join(dir, walFile), os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	s.wal = wal
+
+	if err := s.replay(); err != nil {
+		wal.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *Store) loadSnapshot() error {
+	data, err := os.ReadFile(filepath.Join(s.dir, snapshotFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("load snapshot: %w", err)
+	}
+
+	for _, a := range snap.Accounts {
+		s.m.insert(a)
+	}
+	if snap.NextID > s.m.nextID {
+		s.m.nextID = snap.NextID
+	}
+	s.seq = snap.Seq
+	return nil
+}
+
+// replay applies the WAL on top of the snapshot and cuts off a torn tail.
+func (s *Store) replay() error {
+	if _, err := s.wal.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	r := bufio.NewReader(s.wal)
+	var good int64 // offset right after the last intact record
+
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			break // an empty tail, or a record whose newline never made it to disk
+		}
+		if err != nil {
+			return err
+		}
+
+		rec, ok := decodeRecord(line)
+		if !ok {
+			if _, err := r.Peek(1); err == io.EOF {
+				break // torn last record
+			}
+			return fmt.Errorf("%w: bad record at offset %d", ErrCorruptLog, good)
+		}
+
+		if rec.Seq > s.seq {
+			if err := s.apply(rec); err != nil {
+				return fmt.Errorf("%w: replay seq %d: %v", ErrCorruptLog, rec.Seq, err)
+			}
+			s.seq = rec.Seq
+			s.sinceSnapshot++
+		}
+		good += int64(len(line))
+	}
+
+	if err := s.wal.Truncate(good); err != nil {
+		return err
+	}
+	_, err := s.wal.Seek(good, io.SeekStart)
+	return err
+}
+
+func encodeRecord(rec walRecord) ([]byte, error) {
+	payload, err := json.Marshal(rec)
+	if err != nil {
+		return nil, err
+	}
+	return fmt.Appendf(nil, "%08x %s\n", crc32.ChecksumIEEE(payload), payload), nil
+}
+
+func decodeRecord(line []byte) (walRecord, bool) {
+	var rec walRecord
+
+	line = bytes.TrimSuffix(line, []byte("\n"))
+	sum, payload, found := bytes.Cut(line, []byte(" "))
+	if !found {
+		return rec, false
+	}
+
+	var want uint32
+	if _, err := fmt.Sscanf(string(sum), "%08x", &want); err != nil {
+		return rec, false
+	}
+	if crc32.ChecksumIEEE(payload) != want {
+		return rec, false
+	}
+
+	if err := json.Unmarshal(payload, &rec); err != nil {
+		return rec, false
+	}
+	return rec, true
+}
+
+func (s *Store) apply(rec walRecord) error {
+	switch rec.Op {
+	case opOpen:
+		s.m.insert(Account{ID: rec.ID, Name: rec.Name})
+		return nil
+	case opDeposit:
+		return s.m.Deposit(rec.ID, rec.Amount)
+	case opWithdraw:
+		return s.m.WithDraw(rec.ID, rec.Amount)
+	default:
+		return fmt.Errorf("unknown op %q", rec.Op)
+	}
+}
+
+// commit appends rec to the WAL, fsyncs it and only then applies it in memory.
+// Callers validate the operation first, so apply can't fail after the record is durable.
+// Once the record is durable the operation has happened: a failed automatic snapshot after
+// it is kept for SnapshotErr and tried again on the next commit, not reported as the
+// operation failing, or a caller would retry it and apply it twice.
+//
+// A failed Write or Sync may still have put (part of) the record in the log. It is cut off
+// again so the next commit can reuse the seq; if even that fails the log no longer matches
+// memory and the store refuses all further writes.
+func (s *Store) commit(rec walRecord) error {
+	if s.failed != nil {
+		return s.failed
+	}
+	rec.Seq = s.seq + 1
+
+	line, err := encodeRecord(rec)
+	if err != nil {
+		return err
+	}
+	offset, err := s.wal.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	_, err = s.wal.Write(line)
+	if err == nil {
+		err = s.wal.Sync()
+	}
+	if err != nil {
+		return s.rollback(offset, err)
+	}
+
+	s.seq = rec.Seq
+	if err := s.apply(rec); err != nil {
+		return err
+	}
+
+	s.sinceSnapshot++
+	if s.snapshotEvery > 0 && s.sinceSnapshot >= s.snapshotEvery {
+		s.snapshotErr = s.snapshot()
+	}
+	return nil
+}
+
+// rollback truncates the log back to offset after a failed append.
+func (s *Store) rollback(offset int64, cause error) error {
+	if err := s.wal.Truncate(offset); err != nil {
+		s.failed = fmt.Errorf("%w: %v (rollback: %v)", ErrStoreFailed, cause, err)
+		return s.failed
+	}
+	if _, err := s.wal.Seek(offset, io.SeekStart); err != nil {
+		s.failed = fmt.Errorf("%w: %v (rollback: %v)", ErrStoreFailed, cause, err)
+		return s.failed
+	}
+	return cause
+}
+
+func (s *Store) OpenAccount(name string) (Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a := Account{ID: s.m.nextID, Name: name}
+	if err := s.commit(walRecord{Op: opOpen, ID: a.ID, Name: name}); err != nil {
+		return Account{}, err
+	}
+	return a, nil
+}
+
+func (s *Store) Deposit(id int, amount float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.m.GetBalance(id); err != nil {
+		return err
+	}
+	return s.commit(walRecord{Op: opDeposit, ID: id, Amount: amount})
+}
+
+func (s *Store) WithDraw(id int, amount float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	balance, err := s.m.GetBalance(id)
+	if err != nil {
+		return err
+	}
+	if balance < amount {
+		return ErrInsufficientFunds
+	}
+	return s.commit(walRecord{Op: opWithdraw, ID: id, Amount: amount})
+}
+
+func (s *Store) GetBalance(id int) (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.m.GetBalance(id)
+}
+
+// Snapshot forces a snapshot and truncates the WAL.
+func (s *Store) Snapshot() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.failed != nil {
+		return s.failed
+	}
+	s.snapshotErr = s.snapshot()
+	return s.snapshotErr
+}
+
+// SnapshotErr returns why the last snapshot failed, or nil if it succeeded. Nothing is lost
+// when it fails: the WAL still holds every operation, it just takes longer to replay.
+func (s *Store) SnapshotErr() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.snapshotErr
+}
+
+// snapshot writes to a temp file and renames it into place, so a crash leaves either
+// the old or the new snapshot. The WAL is truncated only after the rename; if we crash
+// in between, replay skips the records already covered by the snapshot's seq.
+func (s *Store) snapshot() error {
+	data, err := json.Marshal(snapshot{
+		Seq:      s.seq,
+		NextID:   s.m.nextID,
+		Accounts: s.m.accounts,
+	})
+	if err != nil {
+		return err
+	}
+
+	tmp := filepath.Join(s.dir, snapshotFile+".tmp")
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(s.dir, snapshotFile)); err != nil {
+		return err
+	}
+	if err := syncDir(s.dir); err != nil {
+		return err
+	}
+
+	// the snapshot covers every record, so a WAL that couldn't be truncated is merely long;
+	// but if the write position is lost the next append would go to the wrong place
+	if err := s.wal.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := s.wal.Seek(0, io.SeekStart); err != nil {
+		s.failed = fmt.Errorf("%w: truncate wal: %v", ErrStoreFailed, err)
+		return s.failed
+	}
+	if err := s.wal.Sync(); err != nil {
+		return err
+	}
+
+	s.sinceSnapshot = 0
+	return nil
+}
+
+// syncDir makes the rename itself durable.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.wal.Close()
+}
@@ -0,0 +1,186 @@
+package account
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func openStore(t *testing.T, dir string, snapshotEvery int) *Store {
+	t.Helper()
+	s, err := OpenStore(dir, snapshotEvery)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func wantBalance(t *testing.T, s *Store, id int, want float64) {
+	t.Helper()
+	if got, err := s.GetBalance(id); err != nil || got != want {
+		t.Errorf("balance of %d = %v, %v; want %v", id, got, err, want)
+	}
+}
+
+func TestStoreRecoversFromWAL(t *testing.T) {
+	dir := t.TempDir()
+	s := openStore(t, dir, 0)
+	a, _ := s.OpenAccount("Alice")
+	s.Deposit(a.ID, 100)
+	s.WithDraw(a.ID, 30)
+	s.Close()
+
+	s = openStore(t, dir, 0)
+	defer s.Close()
+	wantBalance(t, s, a.ID, 70)
+	if b, _ := s.OpenAccount("Bob"); b.ID == a.ID {
+		t.Errorf("recovered store reused account id %d", b.ID)
+	}
+}
+
+func TestStoreDropsTornTail(t *testing.T) {
+	dir := t.TempDir()
+	s := openStore(t, dir, 0)
+	a, _ := s.OpenAccount("Alice")
+	s.Deposit(a.ID, 100)
+	s.Close()
+
+	// a crash halfway through the next append
+	wal := filepath.Join(dir, walFile)
+	intact, err := os.ReadFile(wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	line, _ := encodeRecord(walRecord{Seq: 3, Op: opDeposit, ID: a.ID, Amount: 50})
+	os.WriteFile(wal, append(intact, line[:len(line)/2]...), 0o644)
+
+	s = openStore(t, dir, 0)
+	wantBalance(t, s, a.ID, 100)
+	if err := s.Deposit(a.ID, 1); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	s = openStore(t, dir, 0)
+	defer s.Close()
+	wantBalance(t, s, a.ID, 101)
+}
+
+func TestStoreRejectsCorruptRecord(t *testing.T) {
+	dir := t.TempDir()
+	s := openStore(t, dir, 0)
+	a, _ := s.OpenAccount("Alice")
+	s.Deposit(a.ID, 100)
+	s.Close()
+
+	// a bad record with more data after it isn't a torn tail
+	wal := filepath.Join(dir, walFile)
+	data, _ := os.ReadFile(wal)
+	data[0] ^= 1
+	os.WriteFile(wal, data, 0o644)
+
+	if _, err := OpenStore(dir, 0); !errors.Is(err, ErrCorruptLog) {
+		t.Errorf("OpenStore = %v, want ErrCorruptLog", err)
+	}
+}
+
+// failingWAL writes only half of a line and then fails, the way a full disk does.
+type failingWAL struct {
+	logFile
+	failWrites    int
+	failTruncates bool
+}
+
+func (f *failingWAL) Write(p []byte) (int, error) {
+	if f.failWrites > 0 {
+		f.failWrites--
+		n, _ := f.logFile.Write(p[:len(p)/2])
+		return n, errors.New("disk full")
+	}
+	return f.logFile.Write(p)
+}
+
+func (f *failingWAL) Truncate(size int64) error {
+	if f.failTruncates {
+		return errors.New("read-only filesystem")
+	}
+	return f.logFile.Truncate(size)
+}
+
+func TestStoreRollsBackFailedAppend(t *testing.T) {
+	dir := t.TempDir()
+	s := openStore(t, dir, 0)
+	a, _ := s.OpenAccount("Alice")
+	s.wal = &failingWAL{logFile: s.wal, failWrites: 1}
+
+	if err := s.Deposit(a.ID, 100); err == nil {
+		t.Fatal("Deposit succeeded through a failing write")
+	}
+	wantBalance(t, s, a.ID, 0)
+	if err := s.Deposit(a.ID, 10); err != nil {
+		t.Fatalf("Deposit after the failure: %v", err)
+	}
+	s.Close()
+
+	s = openStore(t, dir, 0)
+	defer s.Close()
+	wantBalance(t, s, a.ID, 10)
+}
+
+func TestStoreFailsWhenRollbackFails(t *testing.T) {
+	s := openStore(t, t.TempDir(), 0)
+	defer s.Close()
+	a, _ := s.OpenAccount("Alice")
+	s.wal = &failingWAL{logFile: s.wal, failWrites: 1, failTruncates: true}
+
+	if err := s.Deposit(a.ID, 100); !errors.Is(err, ErrStoreFailed) {
+		t.Fatalf("Deposit with a failed rollback = %v, want ErrStoreFailed", err)
+	}
+	if err := s.Deposit(a.ID, 1); !errors.Is(err, ErrStoreFailed) {
+		t.Errorf("Deposit on a failed store = %v, want ErrStoreFailed", err)
+	}
+}
+
+func TestStoreSnapshotTruncatesWAL(t *testing.T) {
+	dir := t.TempDir()
+	s := openStore(t, dir, 3)
+	a, _ := s.OpenAccount("Alice")
+	s.Deposit(a.ID, 100)
+	s.Deposit(a.ID, 20) // third operation: snapshot
+
+	if info, err := os.Stat(filepath.Join(dir, walFile)); err != nil || info.Size() != 0 {
+		t.Fatalf("wal after the snapshot: %v, %v; want empty", info.Size(), err)
+	}
+	s.WithDraw(a.ID, 5) // only this one is in the WAL
+	s.Close()
+
+	s = openStore(t, dir, 3)
+	defer s.Close()
+	wantBalance(t, s, a.ID, 115)
+}
+
+func TestStoreSnapshotFailureKeepsTheOperation(t *testing.T) {
+	dir := t.TempDir()
+	// a directory in the way of the temp file makes every snapshot fail
+	if err := os.Mkdir(filepath.Join(dir, snapshotFile+".tmp"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	s := openStore(t, dir, 1)
+
+	a, err := s.OpenAccount("Alice")
+	if err != nil || a.ID == 0 {
+		t.Fatalf("OpenAccount = %+v, %v; want the account despite the failed snapshot", a, err)
+	}
+	if err := s.Deposit(a.ID, 100); err != nil {
+		t.Fatalf("Deposit = %v, want nil despite the failed snapshot", err)
+	}
+	if s.SnapshotErr() == nil {
+		t.Error("SnapshotErr() = nil after a failed snapshot")
+	}
+	s.Close()
+
+	s = openStore(t, dir, 0)
+	defer s.Close()
+	wantBalance(t, s, a.ID, 100)
+}
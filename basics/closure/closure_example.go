@@ -13,7 +13,8 @@ func NewCounter() func() int {
 
 // Example 2: MakeHandler creates a callback function that tracks the number of times it is called.
 type Button struct {
-	Label string
+	EventEmitter // multiple listeners per event, see events.go
+	Label        string
 }
 
 func (b *Button) OnClick(handle func()) {
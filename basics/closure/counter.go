@@ -0,0 +1,179 @@
+package closure
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// A family of counters built from closures. NewCounter above only counts up by 1;
+// MakeCounter is configured with functional options and hands back three closures
+// that share the same captured state:
+//
+// next, reset, peek := MakeCounter(WithStart(10), WithStep(5), WithMax(20, Wrap))
+// next()  // 15, nil
+// next()  // 20, nil
+// next()  // 10, nil  (wrapped back to start)
+// peek()  // 10
+// reset() // back to 10
+
+var ErrCounterOverflow = errors.New("counter reached its max")
+
+type OverflowPolicy int
+
+const (
+	Wrap  OverflowPolicy = iota // go back to the start value
+	Error                       // stay at the last value and return ErrCounterOverflow
+)
+
+type counterConfig struct {
+	start    int
+	step     int
+	max      int
+	hasMax   bool
+	overflow OverflowPolicy
+}
+
+type CounterOption func(*counterConfig)
+
+func WithStart(start int) CounterOption {
+	return func(c *counterConfig) {
+		c.start = start
+	}
+}
+
+func WithStep(step int) CounterOption {
+	return func(c *counterConfig) {
+		c.step = step
+	}
+}
+
+func WithMax(max int, overflow OverflowPolicy) CounterOption {
+	return func(c *counterConfig) {
+		c.max = max
+		c.hasMax = true
+		c.overflow = overflow
+	}
+}
+
+func newCounterConfig(opts []CounterOption) counterConfig {
+	c := counterConfig{step: 1}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// advance computes the value after cur; it's shared by the plain and atomic counters.
+func (c counterConfig) advance(cur int) (int, error) {
+	next := cur + c.step
+	if c.hasMax && next > c.max {
+		if c.overflow == Error {
+			return cur, ErrCounterOverflow
+		}
+		return c.start, nil
+	}
+	return next, nil
+}
+
+// MakeCounter is not safe for concurrent use; see MakeAtomicCounter.
+func MakeCounter(opts ...CounterOption) (next func() (int, error), reset func(), peek func() int) {
+	cfg := newCounterConfig(opts)
+	count := cfg.start
+
+	next = func() (int, error) {
+		v, err := cfg.advance(count)
+		count = v
+		return v, err
+	}
+	reset = func() {
+		count = cfg.start
+	}
+	peek = func() int {
+		return count
+	}
+	return next, reset, peek
+}
+
+// MakeAtomicCounter has the same behavior as MakeCounter but can be shared between goroutines.
+// next uses a compare-and-swap loop, so the step and the max check happen as one atomic update.
+func MakeAtomicCounter(opts ...CounterOption) (next func() (int, error), reset func(), peek func() int) {
+	cfg := newCounterConfig(opts)
+	var count atomic.Int64
+	count.Store(int64(cfg.start))
+
+	next = func() (int, error) {
+		for {
+			cur := count.Load()
+			v, err := cfg.advance(int(cur))
+			if err != nil {
+				return v, err
+			}
+			if count.CompareAndSwap(cur, int64(v)) {
+				return v, nil
+			}
+		}
+	}
+	reset = func() {
+		count.Store(int64(cfg.start))
+	}
+	peek = func() int {
+		return int(count.Load())
+	}
+	return next, reset, peek
+}
+
+// CounterSet keeps one atomic counter per key, created on first use with the same options,
+// e.g. counting requests per route in a handler.
+type CounterSet struct {
+	mu       sync.Mutex
+	opts     []CounterOption
+	counters map[string]*keyedCounter
+}
+
+type keyedCounter struct {
+	next  func() (int, error)
+	reset func()
+	peek  func() int
+}
+
+func NewCounterSet(opts ...CounterOption) *CounterSet {
+	return &CounterSet{opts: opts, counters: map[string]*keyedCounter{}}
+}
+
+func (s *CounterSet) counter(key string) *keyedCounter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.counters[key]
+	if !ok {
+		next, reset, peek := MakeAtomicCounter(s.opts...)
+		c = &keyedCounter{next: next, reset: reset, peek: peek}
+		s.counters[key] = c
+	}
+	return c
+}
+
+func (s *CounterSet) Inc(key string) (int, error) {
+	return s.counter(key).next()
+}
+
+func (s *CounterSet) Get(key string) int {
+	return s.counter(key).peek()
+}
+
+func (s *CounterSet) Reset(key string) {
+	s.counter(key).reset()
+}
+
+// Snapshot returns the current value of every key.
+func (s *CounterSet) Snapshot() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	values := make(map[string]int, len(s.counters))
+	for key, c := range s.counters {
+		values[key] = c.peek()
+	}
+	return values
+}
@@ -0,0 +1,138 @@
+package closure
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+	"testing"
+)
+
+type counterMaker func(...CounterOption) (func() (int, error), func(), func() int)
+
+var counterMakers = map[string]counterMaker{
+	"MakeCounter":       MakeCounter,
+	"MakeAtomicCounter": MakeAtomicCounter,
+}
+
+func TestCounters(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []CounterOption
+		want []int
+		errs int // how many of the calls return ErrCounterOverflow
+	}{
+		{"defaults", nil, []int{1, 2, 3}, 0},
+		{"start and step", []CounterOption{WithStart(10), WithStep(5)}, []int{15, 20, 25}, 0},
+		{"negative step", []CounterOption{WithStep(-2)}, []int{-2, -4, -6}, 0},
+		{"wrap", []CounterOption{WithStart(10), WithStep(5), WithMax(20, Wrap)}, []int{15, 20, 10, 15}, 0},
+		{"error at max", []CounterOption{WithStep(2), WithMax(5, Error)}, []int{2, 4, 4, 4}, 2},
+		{"max reached exactly", []CounterOption{WithMax(2, Error)}, []int{1, 2, 2}, 1},
+	}
+	for name, newCounter := range counterMakers {
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				next, reset, peek := newCounter(tt.opts...)
+				start := peek()
+
+				var got []int
+				errs := 0
+				for range tt.want {
+					v, err := next()
+					if errors.Is(err, ErrCounterOverflow) {
+						errs++
+					} else if err != nil {
+						t.Fatal(err)
+					}
+					got = append(got, v)
+				}
+				if !slices.Equal(got, tt.want) || errs != tt.errs {
+					t.Errorf("next = %v with %d overflows, want %v with %d", got, errs, tt.want, tt.errs)
+				}
+				if p := peek(); p != got[len(got)-1] {
+					t.Errorf("peek = %d, want %d", p, got[len(got)-1])
+				}
+
+				reset()
+				if p := peek(); p != start {
+					t.Errorf("peek after reset = %d, want %d", p, start)
+				}
+				if v, _ := next(); v != tt.want[0] {
+					t.Errorf("next after reset = %d, want %d", v, tt.want[0])
+				}
+			})
+		}
+	}
+}
+
+func TestAtomicCounterConcurrent(t *testing.T) {
+	const goroutines, perGoroutine = 8, 1000
+
+	t.Run("counts every call", func(t *testing.T) {
+		next, _, peek := MakeAtomicCounter()
+		var wg sync.WaitGroup
+		for range goroutines {
+			wg.Go(func() {
+				for range perGoroutine {
+					next()
+				}
+			})
+		}
+		wg.Wait()
+		if got := peek(); got != goroutines*perGoroutine {
+			t.Errorf("count = %d, want %d", got, goroutines*perGoroutine)
+		}
+	})
+
+	t.Run("never passes max", func(t *testing.T) {
+		const limit = 5000
+		next, _, peek := MakeAtomicCounter(WithMax(limit, Error))
+		var mu sync.Mutex
+		seen := map[int]bool{}
+		var wg sync.WaitGroup
+		for range goroutines {
+			wg.Go(func() {
+				for range perGoroutine {
+					v, err := next()
+					if err != nil {
+						continue
+					}
+					mu.Lock()
+					if seen[v] {
+						t.Errorf("value %d handed out twice", v)
+					}
+					seen[v] = true
+					mu.Unlock()
+				}
+			})
+		}
+		wg.Wait()
+		if len(seen) != limit || peek() != limit {
+			t.Errorf("%d values handed out, count %d; want %d and %d", len(seen), peek(), limit, limit)
+		}
+	})
+}
+
+func TestCounterSet(t *testing.T) {
+	s := NewCounterSet(WithStep(2))
+	var wg sync.WaitGroup
+	for _, key := range []string{"/a", "/b", "/a"} {
+		wg.Go(func() {
+			for range 100 {
+				s.Inc(key)
+			}
+		})
+	}
+	wg.Wait()
+
+	if got, want := s.Snapshot(), map[string]int{"/a": 400, "/b": 200}; !maps.Equal(got, want) {
+		t.Errorf("Snapshot = %v, want %v", got, want)
+	}
+	s.Reset("/a")
+	if got := s.Get("/a"); got != 0 {
+		t.Errorf("Get after Reset = %d, want 0", got)
+	}
+	if got := s.Get("/c"); got != 0 || len(s.Snapshot()) != 3 {
+		t.Errorf("Get of a new key = %d, keys %v", got, s.Snapshot())
+	}
+}
@@ -0,0 +1,209 @@
+package closure
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A small UI-style event system. Button.OnClick calls one handler right away;
+// an EventEmitter instead keeps any number of listeners per event type, each
+// a closure that receives an *Event describing what happened:
+//
+// token := btn.On(Click, func(e *Event) { ... })
+// btn.Click(Shift)       // every Click listener runs, in registration order
+// btn.Unsubscribe(token) // that listener is gone
+//
+// A listener can call e.StopPropagation() to keep later listeners from running.
+// A panicking listener is recovered and reported, the other listeners still run.
+// With SetAsync(true) events are dispatched on their own goroutine; Wait blocks until they're delivered.
+
+type EventType string
+
+const (
+	Click       EventType = "click"
+	DoubleClick EventType = "dblclick"
+	Change      EventType = "change"
+)
+
+type Modifier int
+
+const (
+	Shift Modifier = 1 << iota
+	Ctrl
+	Alt
+	Meta
+)
+
+func (m Modifier) String() string {
+	var names []string
+	for _, mod := range []struct {
+		flag Modifier
+		name string
+	}{{Shift, "Shift"}, {Ctrl, "Ctrl"}, {Alt, "Alt"}, {Meta, "Meta"}} {
+		if m&mod.flag != 0 {
+			names = append(names, mod.name)
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, "+")
+}
+
+type Event struct {
+	Type      EventType
+	Source    string // label of the widget that fired the event
+	Time      time.Time
+	Modifiers Modifier
+	Payload   any
+
+	stopped bool
+}
+
+func (e *Event) StopPropagation() {
+	e.stopped = true
+}
+
+func (e *Event) Stopped() bool {
+	return e.stopped
+}
+
+type Listener func(e *Event)
+
+// Token identifies a registered listener so it can be removed later.
+type Token uint64
+
+type registration struct {
+	token    Token
+	listener Listener
+}
+
+// EventEmitter is meant to be embedded in widgets; its zero value is ready to use.
+type EventEmitter struct {
+	mu        sync.RWMutex
+	listeners map[EventType][]registration
+	nextToken Token
+	async     bool
+	onPanic   func(e *Event, r any)
+	wg        sync.WaitGroup
+}
+
+func (em *EventEmitter) On(t EventType, l Listener) Token {
+	em.mu.Lock()
+	defer em.mu.Unlock()
+
+	if em.listeners == nil {
+		em.listeners = map[EventType][]registration{}
+	}
+	em.nextToken++
+	em.listeners[t] = append(em.listeners[t], registration{token: em.nextToken, listener: l})
+	return em.nextToken
+}
+
+// Unsubscribe removes the listener registered with token and reports whether it was found.
+func (em *EventEmitter) Unsubscribe(token Token) bool {
+	em.mu.Lock()
+	defer em.mu.Unlock()
+
+	for t, regs := range em.listeners {
+		for i, reg := range regs {
+			if reg.token == token {
+				// copy instead of removing in place, so an Emit holding the old slice is unaffected
+				em.listeners[t] = append(append([]registration(nil), regs[:i]...), regs[i+1:]...)
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SetAsync switches between dispatching on the caller's goroutine and on a new one.
+func (em *EventEmitter) SetAsync(async bool) {
+	em.mu.Lock()
+	defer em.mu.Unlock()
+
+	em.async = async
+}
+
+// OnPanic sets the function called when a listener panics; by default the panic is printed.
+func (em *EventEmitter) OnPanic(fn func(e *Event, r any)) {
+	em.mu.Lock()
+	defer em.mu.Unlock()
+
+	em.onPanic = fn
+}
+
+func (em *EventEmitter) Emit(e *Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	em.mu.RLock()
+	regs := em.listeners[e.Type] // listeners added or removed during dispatch don't affect this event
+	async := em.async
+	onPanic := em.onPanic
+	em.mu.RUnlock()
+
+	if !async {
+		dispatch(e, regs, onPanic)
+		return
+	}
+
+	em.wg.Add(1)
+	go func() {
+		defer em.wg.Done()
+		dispatch(e, regs, onPanic)
+	}()
+}
+
+// Wait blocks until every asynchronously emitted event has been delivered.
+func (em *EventEmitter) Wait() {
+	em.wg.Wait()
+}
+
+func dispatch(e *Event, regs []registration, onPanic func(e *Event, r any)) {
+	for _, reg := range regs {
+		if e.stopped {
+			return
+		}
+		callListener(e, reg.listener, onPanic)
+	}
+}
+
+// callListener isolates a listener's panic so it can't break the rest of the dispatch.
+func callListener(e *Event, l Listener, onPanic func(e *Event, r any)) {
+	defer func() {
+		if r := recover(); r != nil {
+			if onPanic != nil {
+				onPanic(e, r)
+				return
+			}
+			fmt.Printf("listener for %s on %s panicked: %v\n", e.Type, e.Source, r)
+		}
+	}()
+
+	l(e)
+}
+
+// Click fires a Click event from the button with the given modifier keys held.
+func (b *Button) Click(mods Modifier) {
+	b.Emit(&Event{Type: Click, Source: b.Label, Modifiers: mods})
+}
+
+func (b *Button) DoubleClick(mods Modifier) {
+	b.Emit(&Event{Type: DoubleClick, Source: b.Label, Modifiers: mods})
+}
+
+// TextInput is another widget; it fires Change with the new text as payload.
+type TextInput struct {
+	EventEmitter
+	Label string
+	Value string
+}
+
+func (t *TextInput) SetValue(v string) {
+	t.Value = v
+	t.Emit(&Event{Type: Change, Source: t.Label, Payload: v})
+}
@@ -0,0 +1,112 @@
+package closure
+
+import (
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestListenersRunInOrder(t *testing.T) {
+	btn := &Button{Label: "OK"}
+	var got []string
+	btn.On(Click, func(e *Event) { got = append(got, "first "+e.Modifiers.String()) })
+	btn.On(Click, func(e *Event) { got = append(got, "second "+e.Source) })
+	btn.On(DoubleClick, func(e *Event) { got = append(got, "double") })
+
+	btn.Click(Shift | Ctrl)
+	if want := []string{"first Shift+Ctrl", "second OK"}; !slices.Equal(got, want) {
+		t.Errorf("listeners ran %q, want %q", got, want)
+	}
+}
+
+func TestUnsubscribe(t *testing.T) {
+	btn := &Button{Label: "OK"}
+	calls := 0
+	token := btn.On(Click, func(*Event) { calls++ })
+
+	btn.Click(0)
+	if !btn.Unsubscribe(token) {
+		t.Fatal("Unsubscribe of a registered listener = false")
+	}
+	btn.Click(0)
+	if calls != 1 {
+		t.Errorf("listener ran %d times, want 1", calls)
+	}
+	if btn.Unsubscribe(token) {
+		t.Error("second Unsubscribe = true")
+	}
+}
+
+func TestUnsubscribeDuringDispatch(t *testing.T) {
+	btn := &Button{Label: "OK"}
+	var got []int
+	var second Token
+	btn.On(Click, func(*Event) {
+		got = append(got, 1)
+		btn.Unsubscribe(second) // this event already has its listeners
+	})
+	second = btn.On(Click, func(*Event) { got = append(got, 2) })
+
+	btn.Click(0)
+	btn.Click(0)
+	if want := []int{1, 2, 1}; !slices.Equal(got, want) {
+		t.Errorf("listeners ran %v, want %v", got, want)
+	}
+}
+
+func TestStopPropagation(t *testing.T) {
+	btn := &Button{Label: "OK"}
+	var got []int
+	btn.On(Click, func(e *Event) {
+		got = append(got, 1)
+		if e.Modifiers&Alt != 0 {
+			e.StopPropagation()
+		}
+	})
+	btn.On(Click, func(*Event) { got = append(got, 2) })
+
+	btn.Click(Alt)
+	btn.Click(0)
+	if want := []int{1, 1, 2}; !slices.Equal(got, want) {
+		t.Errorf("listeners ran %v, want %v", got, want)
+	}
+}
+
+func TestPanickingListener(t *testing.T) {
+	input := &TextInput{Label: "name"}
+	var recovered []any
+	input.OnPanic(func(e *Event, r any) { recovered = append(recovered, r) })
+
+	var payload any
+	input.On(Change, func(*Event) { panic("listener bug") })
+	input.On(Change, func(e *Event) { payload = e.Payload })
+
+	input.SetValue("Alice")
+	if payload != "Alice" || input.Value != "Alice" {
+		t.Errorf("payload = %v, value %q; want the later listener to get Alice", payload, input.Value)
+	}
+	if len(recovered) != 1 || recovered[0] != "listener bug" {
+		t.Errorf("OnPanic got %v, want the listener's panic", recovered)
+	}
+}
+
+func TestAsyncEmit(t *testing.T) {
+	btn := &Button{Label: "OK"}
+	btn.SetAsync(true)
+
+	var mu sync.Mutex
+	count := 0
+	btn.On(Click, func(e *Event) {
+		mu.Lock()
+		count++
+		mu.Unlock()
+	})
+
+	for range 50 {
+		btn.Click(0)
+	}
+	btn.Wait()
+	if count != 50 {
+		t.Errorf("%d events delivered after Wait, want 50", count)
+	}
+}
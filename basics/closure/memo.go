@@ -0,0 +1,197 @@
+package closure
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Caching and evaluation helpers built on closures: the cache, the lazily computed value
+// and the retry state all live in variables captured by the returned function.
+
+type memoConfig struct {
+	capacity int           // 0 means unbounded
+	ttl      time.Duration // 0 means entries never expire
+	now      func() time.Time
+}
+
+type MemoOption func(*memoConfig)
+
+// WithCapacity bounds the cache; the least recently used entry is evicted first.
+func WithCapacity(n int) MemoOption {
+	return func(c *memoConfig) {
+		c.capacity = n
+	}
+}
+
+func WithTTL(ttl time.Duration) MemoOption {
+	return func(c *memoConfig) {
+		c.ttl = ttl
+	}
+}
+
+// WithNow replaces time.Now, so TTL expiry can be tested without sleeping.
+func WithNow(now func() time.Time) MemoOption {
+	return func(c *memoConfig) {
+		c.now = now
+	}
+}
+
+type MemoStats struct {
+	Hits, Misses, Evictions int
+	Size                    int
+}
+
+type memoEntry[K comparable, V any] struct {
+	key     K
+	value   V
+	expires time.Time
+}
+
+// Memoize caches fn's results by argument. It's safe for concurrent use; two callers
+// missing on the same key at once may both call fn, and the last result is kept.
+func Memoize[K comparable, V any](fn func(K) V, opts ...MemoOption) (memo func(K) V, stats func() MemoStats) {
+	cfg := memoConfig{now: time.Now}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	var mu sync.Mutex
+	order := list.New() // front = most recently used
+	entries := map[K]*list.Element{}
+	var st MemoStats
+
+	memo = func(key K) V {
+		mu.Lock()
+		if el, ok := entries[key]; ok {
+			e := el.Value.(*memoEntry[K, V])
+			if cfg.ttl == 0 || cfg.now().Before(e.expires) {
+				order.MoveToFront(el)
+				st.Hits++
+				mu.Unlock()
+				return e.value
+			}
+			order.Remove(el) // expired
+			delete(entries, key)
+		}
+		st.Misses++
+		mu.Unlock()
+
+		value := fn(key) // not under the lock, fn may be slow
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if el, ok := entries[key]; ok {
+			order.Remove(el)
+		}
+		entries[key] = order.PushFront(&memoEntry[K, V]{key: key, value: value, expires: cfg.now().Add(cfg.ttl)})
+		if cfg.capacity > 0 && order.Len() > cfg.capacity {
+			oldest := order.Back()
+			order.Remove(oldest)
+			delete(entries, oldest.Value.(*memoEntry[K, V]).key)
+			st.Evictions++
+		}
+		return value
+	}
+
+	stats = func() MemoStats {
+		mu.Lock()
+		defer mu.Unlock()
+
+		s := st
+		s.Size = order.Len()
+		return s
+	}
+
+	return memo, stats
+}
+
+// Lazy computes its value on the first Get and returns the same value afterwards,
+// even when the first Gets race each other.
+type Lazy[T any] struct {
+	once  sync.Once
+	fn    func() T
+	value T
+}
+
+func NewLazy[T any](fn func() T) *Lazy[T] {
+	return &Lazy[T]{fn: fn}
+}
+
+func (l *Lazy[T]) Get() T {
+	l.once.Do(func() {
+		l.value = l.fn()
+		l.fn = nil // let the closure and what it captured be collected
+	})
+	return l.value
+}
+
+// Backoff returns how long to wait before the given retry (1 for the first retry).
+type Backoff func(retry int) time.Duration
+
+func ConstantBackoff(d time.Duration) Backoff {
+	return func(int) time.Duration {
+		return d
+	}
+}
+
+// ExponentialBackoff doubles the wait after every retry, up to max.
+func ExponentialBackoff(base, max time.Duration) Backoff {
+	return func(retry int) time.Duration {
+		d := base
+		for i := 1; i < retry && d < max; i++ {
+			d *= 2
+		}
+		return min(d, max)
+	}
+}
+
+// Retry wraps fn so that it's tried up to attempts times, sleeping per backoff in between.
+// fn is always tried at least once.
+func Retry(fn func() error, attempts int, backoff Backoff) func() error {
+	attempts = max(attempts, 1)
+	return func() error {
+		var err error
+		for attempt := 1; attempt <= attempts; attempt++ {
+			if err = fn(); err == nil {
+				return nil
+			}
+			if attempt < attempts {
+				time.Sleep(backoff(attempt))
+			}
+		}
+		return fmt.Errorf("gave up after %d attempts: %w", attempts, err)
+	}
+}
+
+// Curry2 turns f(a, b) into f(a)(b); makeAdder is Curry2 of an add function.
+func Curry2[A, B, R any](f func(A, B) R) func(A) func(B) R {
+	return func(a A) func(B) R {
+		return func(b B) R {
+			return f(a, b)
+		}
+	}
+}
+
+func Curry3[A, B, C, R any](f func(A, B, C) R) func(A) func(B) func(C) R {
+	return func(a A) func(B) func(C) R {
+		return Curry2(func(b B, c C) R {
+			return f(a, b, c)
+		})
+	}
+}
+
+func Uncurry2[A, B, R any](f func(A) func(B) R) func(A, B) R {
+	return func(a A, b B) R {
+		return f(a)(b)
+	}
+}
+
+// Partial fixes the first argument of f.
+func Partial[A, B, R any](f func(A, B) R, a A) func(B) R {
+	return func(b B) R {
+		return f(a, b)
+	}
+}
@@ -0,0 +1,164 @@
+package closure
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func slowFib(n int) int {
+	if n < 2 {
+		return n
+	}
+	return slowFib(n-1) + slowFib(n-2)
+}
+
+// BenchmarkMemoize compares a slow function with its memoized versions: a warm cache only
+// pays for a map lookup, a cache smaller than the working set keeps missing.
+func BenchmarkMemoize(b *testing.B) {
+	const keys = 16
+	arg := func(i int) int { return 15 + i%keys }
+
+	run := func(b *testing.B, fn func(int) int, stats func() MemoStats) {
+		for i := 0; i < b.N; i++ {
+			fn(arg(i))
+		}
+		if stats != nil {
+			s := stats()
+			b.ReportMetric(float64(s.Hits)/float64(s.Hits+s.Misses), "hit-ratio")
+			b.ReportMetric(float64(s.Evictions), "evictions")
+		}
+	}
+
+	b.Run("no cache", func(b *testing.B) {
+		run(b, slowFib, nil)
+	})
+	b.Run("memoized", func(b *testing.B) {
+		fn, stats := Memoize(slowFib)
+		run(b, fn, stats)
+	})
+	b.Run("capacity 8", func(b *testing.B) {
+		fn, stats := Memoize(slowFib, WithCapacity(keys/2)) // LRU thrashes on a cyclic access pattern
+		run(b, fn, stats)
+	})
+	b.Run("TTL 1µs", func(b *testing.B) {
+		fn, stats := Memoize(slowFib, WithTTL(time.Microsecond))
+		run(b, fn, stats)
+	})
+}
+
+func TestRetryTriesAtLeastOnce(t *testing.T) {
+	for _, attempts := range []int{-1, 0} {
+		calls := 0
+		err := Retry(func() error {
+			calls++
+			return nil
+		}, attempts, ConstantBackoff(0))()
+		if err != nil || calls != 1 {
+			t.Errorf("attempts=%d: err=%v calls=%d, want nil and 1", attempts, err, calls)
+		}
+	}
+}
+
+func TestMemoizeTTL(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	calls := 0
+	double, stats := Memoize(func(n int) int {
+		calls++
+		return n * 2
+	}, WithTTL(time.Minute), WithNow(func() time.Time { return now }))
+
+	double(1)
+	now = now.Add(59 * time.Second)
+	double(1) // still fresh
+	if calls != 1 {
+		t.Fatalf("calls before the TTL = %d, want 1", calls)
+	}
+
+	now = now.Add(time.Second) // exactly a minute old: expired
+	if got := double(1); got != 2 || calls != 2 {
+		t.Fatalf("double(1) after the TTL = %d with %d calls, want 2 and 2", got, calls)
+	}
+	now = now.Add(30 * time.Second)
+	double(1) // the refreshed entry has a new minute
+	if s := stats(); calls != 2 || s.Hits != 2 || s.Misses != 2 || s.Size != 1 {
+		t.Errorf("calls=%d stats=%+v, want 2 calls, 2 hits, 2 misses, size 1", calls, s)
+	}
+}
+
+func TestMemoizeCapacity(t *testing.T) {
+	calls := map[int]int{}
+	square, stats := Memoize(func(n int) int {
+		calls[n]++
+		return n * n
+	}, WithCapacity(2))
+
+	square(1)
+	square(2)
+	square(1) // 1 is now the most recently used
+	square(3) // evicts 2
+	square(1)
+	square(2)
+	if calls[1] != 1 || calls[2] != 2 || calls[3] != 1 {
+		t.Errorf("calls = %v, want 1 computed once and 2 twice", calls)
+	}
+	if s := stats(); s.Evictions != 2 || s.Size != 2 {
+		t.Errorf("stats = %+v, want 2 evictions and size 2", s)
+	}
+}
+
+func TestMemoizeConcurrent(t *testing.T) {
+	var calls atomic.Int64
+	square, stats := Memoize(func(n int) int {
+		calls.Add(1)
+		return n * n
+	}, WithCapacity(8))
+
+	var wg sync.WaitGroup
+	for g := range 16 {
+		wg.Go(func() {
+			for i := range 1000 {
+				n := (g + i) % 10
+				if got := square(n); got != n*n {
+					t.Errorf("square(%d) = %d", n, got)
+					return
+				}
+			}
+		})
+	}
+	wg.Wait()
+
+	s := stats()
+	if s.Hits+s.Misses != 16000 || int64(s.Misses) != calls.Load() || s.Size > 8 {
+		t.Errorf("stats = %+v with %d calls; want 16000 lookups, a call per miss, size <= 8", s, calls.Load())
+	}
+}
+
+func TestLazyComputesOnce(t *testing.T) {
+	var calls atomic.Int64
+	start := make(chan struct{})
+	lazy := NewLazy(func() int {
+		calls.Add(1)
+		return 42
+	})
+	if calls.Load() != 0 {
+		t.Fatal("NewLazy called fn")
+	}
+
+	var wg sync.WaitGroup
+	for range 32 {
+		wg.Go(func() {
+			<-start
+			if got := lazy.Get(); got != 42 {
+				t.Errorf("Get = %d, want 42", got)
+			}
+		})
+	}
+	close(start)
+	wg.Wait()
+	lazy.Get()
+	if n := calls.Load(); n != 1 {
+		t.Errorf("fn called %d times, want 1", n)
+	}
+}
@@ -0,0 +1,226 @@
+package closure
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Middleware wraps a handler in another closure that adds behavior before/after it,
+// the same decorator idea as Combine for prices but for handlers.
+// Handlers are generic over their argument so one set of decorators works for:
+// - plain callbacks like MakeHandler's func()  -> WrapFunc
+// - event listeners func(*Event)               -> WrapListener
+// - HTTP handlers                              -> WrapHTTP
+//
+// handle := WrapFunc(MakeHandler("save"), Recover[struct{}](nil), Throttle[struct{}](time.Second))
+//
+// Stateful middleware (RateLimit, Throttle, Debounce, Once) keeps its state per wrapped handler,
+// so one middleware value can decorate many handlers. Counting is shared on purpose.
+//
+// A dropped call is silent for callbacks and listeners, but an argument implementing Dropper
+// is told about it: HTTPCall answers 429 Too Many Requests instead of an empty 200.
+
+type Func[T any] func(T)
+
+type Middleware[T any] func(next Func[T]) Func[T]
+
+// Chain composes middleware so the first one is the outermost:
+// Chain(a, b)(h) == a(b(h)), i.e. a runs first.
+func Chain[T any](mws ...Middleware[T]) Middleware[T] {
+	return func(next Func[T]) Func[T] {
+		for i := len(mws) - 1; i >= 0; i-- {
+			next = mws[i](next)
+		}
+		return next
+	}
+}
+
+func WrapFunc(f func(), mws ...Middleware[struct{}]) func() {
+	h := Chain(mws...)(func(struct{}) { f() })
+	return func() { h(struct{}{}) }
+}
+
+func WrapListener(l Listener, mws ...Middleware[*Event]) Listener {
+	return Listener(Chain(mws...)(Func[*Event](l)))
+}
+
+// HTTPCall bundles an HTTP handler's arguments so it fits Func[T].
+type HTTPCall struct {
+	W http.ResponseWriter
+	R *http.Request
+}
+
+// Drop replies 429 to a request that RateLimit, Throttle or Once didn't let through.
+func (c HTTPCall) Drop(reason string) {
+	http.Error(c.W, reason, http.StatusTooManyRequests)
+}
+
+// Dropper is implemented by arguments that need an answer when their call is dropped.
+type Dropper interface {
+	Drop(reason string)
+}
+
+func drop[T any](arg T, reason string) {
+	if d, ok := any(arg).(Dropper); ok {
+		d.Drop(reason)
+	}
+}
+
+func WrapHTTP(h http.HandlerFunc, mws ...Middleware[HTTPCall]) http.HandlerFunc {
+	wrapped := Chain(mws...)(func(c HTTPCall) { h(c.W, c.R) })
+	return func(w http.ResponseWriter, r *http.Request) {
+		wrapped(HTTPCall{W: w, R: r})
+	}
+}
+
+// Logging prints when the handler starts and how long it took.
+func Logging[T any](name string) Middleware[T] {
+	return func(next Func[T]) Func[T] {
+		return func(arg T) {
+			start := time.Now()
+			fmt.Printf("[%s] start\n", name)
+			defer func() {
+				fmt.Printf("[%s] done in %v\n", name, time.Since(start))
+			}()
+			next(arg)
+		}
+	}
+}
+
+// Counting counts the calls that reach it; the returned func reads the count.
+func Counting[T any]() (Middleware[T], func() int64) {
+	var calls atomic.Int64
+	mw := func(next Func[T]) Func[T] {
+		return func(arg T) {
+			calls.Add(1)
+			next(arg)
+		}
+	}
+	return mw, calls.Load
+}
+
+// RateLimit lets at most n calls through per window and drops the rest.
+func RateLimit[T any](n int, window time.Duration) Middleware[T] {
+	return func(next Func[T]) Func[T] {
+		var mu sync.Mutex
+		var windowStart time.Time
+		count := 0
+
+		return func(arg T) {
+			mu.Lock()
+			now := time.Now()
+			if now.Sub(windowStart) >= window {
+				windowStart, count = now, 0
+			}
+			allowed := count < n
+			if allowed {
+				count++
+			}
+			mu.Unlock()
+
+			if !allowed {
+				drop(arg, "rate limit exceeded")
+				return
+			}
+			next(arg)
+		}
+	}
+}
+
+// Throttle runs the first call and drops every call for the next interval.
+func Throttle[T any](interval time.Duration) Middleware[T] {
+	return func(next Func[T]) Func[T] {
+		var mu sync.Mutex
+		var last time.Time
+
+		return func(arg T) {
+			mu.Lock()
+			now := time.Now()
+			allowed := last.IsZero() || now.Sub(last) >= interval
+			if allowed {
+				last = now
+			}
+			mu.Unlock()
+
+			if !allowed {
+				drop(arg, "throttled")
+				return
+			}
+			next(arg)
+		}
+	}
+}
+
+// Debounce delays the call until wait has passed without another call,
+// then runs it once with the latest argument.
+//
+// It is fire-and-forget: the handler runs on a timer goroutine after the wrapped call has
+// returned, so middleware outside it (Logging, Recover) only sees the scheduling. A panic in
+// the handler is recovered and printed on that goroutine. HTTP handlers can't be debounced,
+// because the ResponseWriter is gone by the time the handler runs; Debounce[HTTPCall] panics.
+func Debounce[T any](wait time.Duration) Middleware[T] {
+	if _, ok := any(*new(T)).(HTTPCall); ok {
+		panic("closure: Debounce can't wrap HTTP handlers")
+	}
+
+	return func(next Func[T]) Func[T] {
+		var mu sync.Mutex
+		var timer *time.Timer
+
+		return func(arg T) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			if timer != nil {
+				timer.Stop()
+			}
+			timer = time.AfterFunc(wait, func() {
+				defer func() {
+					if r := recover(); r != nil {
+						fmt.Println("debounced handler panic recovered:", r)
+					}
+				}()
+				next(arg)
+			})
+		}
+	}
+}
+
+// Once lets only the first call through.
+func Once[T any]() Middleware[T] {
+	return func(next Func[T]) Func[T] {
+		var once sync.Once
+
+		return func(arg T) {
+			ran := false
+			once.Do(func() {
+				ran = true
+				next(arg)
+			})
+			if !ran {
+				drop(arg, "already handled")
+			}
+		}
+	}
+}
+
+// Recover turns a panic in the handler into a call to onPanic (or a printed message if nil).
+func Recover[T any](onPanic func(r any)) Middleware[T] {
+	return func(next Func[T]) Func[T] {
+		return func(arg T) {
+			defer func() {
+				if r := recover(); r != nil {
+					if onPanic != nil {
+						onPanic(r)
+						return
+					}
+					fmt.Println("handler panic recovered:", r)
+				}
+			}()
+			next(arg)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package closure
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWrapHTTPDroppedRequestsGet429(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) }
+
+	cases := []struct {
+		name string
+		mw   Middleware[HTTPCall]
+	}{
+		{"RateLimit", RateLimit[HTTPCall](1, time.Hour)},
+		{"Throttle", Throttle[HTTPCall](time.Hour)},
+		{"Once", Once[HTTPCall]()},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			h := WrapHTTP(ok, c.mw)
+			for i, want := range []int{http.StatusNoContent, http.StatusTooManyRequests} {
+				rec := httptest.NewRecorder()
+				h(rec, httptest.NewRequest("GET", "/", nil))
+				if rec.Code != want {
+					t.Errorf("request %d: status %d, want %d", i+1, rec.Code, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDebounceRejectsHTTPHandlers(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Debounce[HTTPCall] did not panic")
+		}
+	}()
+	Debounce[HTTPCall](time.Millisecond)
+}
+
+func TestDebounceRecoversPanics(t *testing.T) {
+	done := make(chan struct{})
+	h := WrapFunc(func() {
+		defer close(done)
+		panic("boom")
+	}, Debounce[struct{}](time.Millisecond))
+
+	h()
+	select {
+	case <-done: // the process is still alive
+	case <-time.After(time.Second):
+		t.Fatal("debounced handler never ran")
+	}
+}
@@ -0,0 +1,293 @@
+package closure
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// A pricing engine on top of PriceStrategy. Combine just runs strategies in caller order
+// on a bare price; real promotions also need to know about the cart (what's in it, when
+// it's checked out), need rules about which promotions stack, and must never drive the
+// price below zero. The engine adds:
+// - CartStrategy: a strategy that can look at the cart; Price adapts any PriceStrategy
+// - conditions: MinSpend, TimeWindow, ... decide whether a promotion is eligible
+// - stacking: promotions in the same Group don't stack, only the best one applies;
+//   an Exclusive promotion applies alone, and only if it beats the stack of the others
+// - guards: the price is clamped to the floor after every step and to the ceiling at the end
+// - a trace that explains each promotion's effect, for checkout line items
+
+type LineItem struct {
+	SKU       string
+	UnitPrice float64
+	Quantity  int
+}
+
+type Cart struct {
+	Items []LineItem
+	Time  time.Time // checkout time, for time-windowed promotions
+}
+
+func (c Cart) Subtotal() float64 {
+	total := 0.0
+	for _, item := range c.Items {
+		total += item.UnitPrice * float64(item.Quantity)
+	}
+	return total
+}
+
+func (c Cart) Quantity(sku string) int {
+	qty := 0
+	for _, item := range c.Items {
+		if item.SKU == sku {
+			qty += item.Quantity
+		}
+	}
+	return qty
+}
+
+func (c Cart) unitPrice(sku string) float64 {
+	for _, item := range c.Items {
+		if item.SKU == sku {
+			return item.UnitPrice
+		}
+	}
+	return 0
+}
+
+type CartStrategy func(cart Cart, price float64) float64
+
+// Price adapts a plain PriceStrategy such as DiscountStrategy or WithCoupon.
+func Price(s PriceStrategy) CartStrategy {
+	return func(_ Cart, price float64) float64 {
+		return s(price)
+	}
+}
+
+// QuantityTier gives Off (0.1 = 10%) on a SKU's line once at least MinQuantity units are in the cart.
+type QuantityTier struct {
+	MinQuantity int
+	Off         float64
+}
+
+// QuantityTiers applies the best tier reached by the SKU's quantity.
+func QuantityTiers(sku string, tiers ...QuantityTier) CartStrategy {
+	return func(cart Cart, price float64) float64 {
+		qty := cart.Quantity(sku)
+		off := 0.0
+		for _, t := range tiers {
+			if qty >= t.MinQuantity && t.Off > off {
+				off = t.Off
+			}
+		}
+		return price - cart.unitPrice(sku)*float64(qty)*off
+	}
+}
+
+// BuyXGetY makes y units free for every x+y units of the SKU.
+// It panics unless x and y are both positive, like regexp.MustCompile on a bad pattern.
+func BuyXGetY(sku string, x, y int) CartStrategy {
+	if x <= 0 || y <= 0 {
+		panic(fmt.Sprintf("closure: BuyXGetY(%q, %d, %d): x and y must be positive", sku, x, y))
+	}
+	return func(cart Cart, price float64) float64 {
+		free := cart.Quantity(sku) / (x + y) * y
+		return price - cart.unitPrice(sku)*float64(free)
+	}
+}
+
+type Condition func(cart Cart) bool
+
+func MinSpend(amount float64) Condition {
+	return func(cart Cart) bool {
+		return cart.Subtotal() >= amount
+	}
+}
+
+func MinQuantity(sku string, qty int) Condition {
+	return func(cart Cart) bool {
+		return cart.Quantity(sku) >= qty
+	}
+}
+
+// TimeWindow is met when the checkout time is in [start, end).
+func TimeWindow(start, end time.Time) Condition {
+	return func(cart Cart) bool {
+		return !cart.Time.Before(start) && cart.Time.Before(end)
+	}
+}
+
+func AllOf(conds ...Condition) Condition {
+	return func(cart Cart) bool {
+		for _, c := range conds {
+			if !c(cart) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+type Promotion struct {
+	Name      string
+	Strategy  CartStrategy
+	When      Condition // nil means always eligible
+	Priority  int       // lower runs first when stacking
+	Group     string    // promotions sharing a group don't stack with each other
+	Exclusive bool      // applies alone, never stacked
+}
+
+// Adjustment is one line of the explanation trace.
+type Adjustment struct {
+	Promotion string
+	Before    float64
+	After     float64
+	Skipped   string // why the promotion didn't apply, empty if it did
+}
+
+func (a Adjustment) Effect() float64 {
+	return a.After - a.Before
+}
+
+func (a Adjustment) String() string {
+	if a.Skipped != "" {
+		return fmt.Sprintf("%-20s skipped: %s", a.Promotion, a.Skipped)
+	}
+	return fmt.Sprintf("%-20s %8.2f -> %8.2f (%+.2f)", a.Promotion, a.Before, a.After, a.Effect())
+}
+
+type Quote struct {
+	Subtotal float64
+	Total    float64
+	Trace    []Adjustment
+}
+
+type PricingEngine struct {
+	promotions []Promotion
+	floor      float64
+	ceiling    float64 // 0 means no ceiling
+}
+
+type PricingOption func(*PricingEngine)
+
+// WithFloor keeps the price at or above floor (default 0, so coupons can't make it negative).
+func WithFloor(floor float64) PricingOption {
+	return func(e *PricingEngine) {
+		e.floor = floor
+	}
+}
+
+func WithCeiling(ceiling float64) PricingOption {
+	return func(e *PricingEngine) {
+		e.ceiling = ceiling
+	}
+}
+
+func NewPricingEngine(opts ...PricingOption) *PricingEngine {
+	e := &PricingEngine{}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+func (e *PricingEngine) Add(promotions ...Promotion) {
+	e.promotions = append(e.promotions, promotions...)
+}
+
+func (e *PricingEngine) Quote(cart Cart) Quote {
+	subtotal := cart.Subtotal()
+	var skipped []Adjustment
+
+	// 1. eligibility
+	var eligible []Promotion
+	for _, p := range e.promotions {
+		if p.When != nil && !p.When(cart) {
+			skipped = append(skipped, Adjustment{Promotion: p.Name, Skipped: "condition not met"})
+			continue
+		}
+		eligible = append(eligible, p)
+	}
+	sort.SliceStable(eligible, func(i, j int) bool {
+		return eligible[i].Priority < eligible[j].Priority
+	})
+
+	// 2. one promotion per group: the one that saves the most on its own
+	// (tracked by index: two promotions may share a name)
+	best := map[string]int{}
+	for i, p := range eligible {
+		if p.Group == "" {
+			continue
+		}
+		if cur, ok := best[p.Group]; !ok || e.alone(cart, p) < e.alone(cart, eligible[cur]) {
+			best[p.Group] = i
+		}
+	}
+
+	var stackable, exclusive []Promotion
+	for i, p := range eligible {
+		if p.Group != "" && best[p.Group] != i {
+			winner := eligible[best[p.Group]]
+			skipped = append(skipped, Adjustment{Promotion: p.Name, Skipped: fmt.Sprintf("%s is better in group %q", winner.Name, p.Group)})
+			continue
+		}
+		if p.Exclusive {
+			exclusive = append(exclusive, p)
+		} else {
+			stackable = append(stackable, p)
+		}
+	}
+
+	// 3. the stack of regular promotions competes with each exclusive one
+	total, trace := e.apply(cart, subtotal, stackable)
+	winner := -1
+	for i, p := range exclusive {
+		if t, tr := e.apply(cart, subtotal, []Promotion{p}); t < total {
+			total, trace, winner = t, tr, i
+		}
+	}
+
+	if winner >= 0 {
+		for _, p := range stackable {
+			skipped = append(skipped, Adjustment{Promotion: p.Name, Skipped: fmt.Sprintf("exclusive %s is better", exclusive[winner].Name)})
+		}
+	}
+	for i, p := range exclusive {
+		if i != winner {
+			skipped = append(skipped, Adjustment{Promotion: p.Name, Skipped: "exclusive, the other promotions are better"})
+		}
+	}
+
+	// 4. ceiling
+	if e.ceiling > 0 && total > e.ceiling {
+		trace = append(trace, Adjustment{Promotion: "ceiling", Before: total, After: e.ceiling})
+		total = e.ceiling
+	}
+
+	return Quote{
+		Subtotal: subtotal,
+		Total:    round2(total),
+		Trace:    append(trace, skipped...),
+	}
+}
+
+// apply runs the promotions in order, clamping to the floor after every step.
+func (e *PricingEngine) apply(cart Cart, price float64, promotions []Promotion) (float64, []Adjustment) {
+	var trace []Adjustment
+	for _, p := range promotions {
+		before := price
+		price = math.Max(p.Strategy(cart, price), e.floor)
+		trace = append(trace, Adjustment{Promotion: p.Name, Before: round2(before), After: round2(price)})
+	}
+	return price, trace
+}
+
+func (e *PricingEngine) alone(cart Cart, p Promotion) float64 {
+	price, _ := e.apply(cart, cart.Subtotal(), []Promotion{p})
+	return price
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,410 @@
+package closure
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Pricing rules loaded from a file, so promotions can change without a deploy.
+// A rule file lists discounts and coupons and the order to combine them in;
+// it's turned into a PriceStrategy with DiscountStrategy, WithCoupon and Combine.
+//
+// rules.json:
+// {
+//   "rules": [
+//     {"name": "spring-sale", "type": "discount", "off": 0.2},
+//     {"name": "welcome", "type": "coupon", "amount": 5}
+//   ],
+//   "order": ["spring-sale", "welcome"]
+// }
+//
+// rules.yaml (the same, in a small YAML subset: lists of "key: value" maps and scalars):
+// rules:
+//   - name: spring-sale
+//     type: discount
+//     off: 0.2
+//   - name: welcome
+//     type: coupon
+//     amount: 5
+// order:
+//   - spring-sale
+//   - welcome
+//
+// "order" is optional and defaults to the order of "rules".
+
+type PricingRule struct {
+	Name   string  `json:"name"`
+	Type   string  `json:"type"` // discount or coupon
+	Off    float64 `json:"off,omitempty"`
+	Amount float64 `json:"amount,omitempty"`
+}
+
+type PricingConfig struct {
+	Rules []PricingRule `json:"rules"`
+	Order []string      `json:"order,omitempty"`
+}
+
+// RuleConfigError names the rule that failed validation.
+type RuleConfigError struct {
+	Rule string
+	Msg  string
+}
+
+func (e *RuleConfigError) Error() string {
+	return fmt.Sprintf("pricing rule %q: %s", e.Rule, e.Msg)
+}
+
+// Validate reports every problem in the config, not just the first one.
+func (c PricingConfig) Validate() error {
+	var errs []error
+	seen := map[string]bool{}
+
+	for i, r := range c.Rules {
+		name := r.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+			errs = append(errs, &RuleConfigError{Rule: name, Msg: "name is required"})
+		} else if seen[name] {
+			errs = append(errs, &RuleConfigError{Rule: name, Msg: "duplicate name"})
+		}
+		seen[r.Name] = true
+
+		switch r.Type {
+		case "discount":
+			if r.Off <= 0 || r.Off > 1 {
+				errs = append(errs, &RuleConfigError{Rule: name, Msg: fmt.Sprintf("off must be in (0, 1], got %v", r.Off)})
+			}
+		case "coupon":
+			if r.Amount <= 0 {
+				errs = append(errs, &RuleConfigError{Rule: name, Msg: fmt.Sprintf("amount must be positive, got %v", r.Amount)})
+			}
+		default:
+			errs = append(errs, &RuleConfigError{Rule: name, Msg: fmt.Sprintf("unknown type %q, want discount or coupon", r.Type)})
+		}
+	}
+
+	ordered := map[string]bool{}
+	for _, name := range c.Order {
+		if !seen[name] {
+			errs = append(errs, &RuleConfigError{Rule: name, Msg: "listed in order but not defined"})
+		}
+		if ordered[name] {
+			errs = append(errs, &RuleConfigError{Rule: name, Msg: "listed in order more than once"})
+		}
+		ordered[name] = true
+	}
+
+	return errors.Join(errs...)
+}
+
+// Build validates the config and combines its rules into one strategy.
+// Like PricingEngine's default floor, the price is clamped at 0 after every rule,
+// so a coupon bigger than the price makes it free instead of negative.
+func (c PricingConfig) Build() (PriceStrategy, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	byName := map[string]PriceStrategy{}
+	for _, r := range c.Rules {
+		if r.Type == "discount" {
+			byName[r.Name] = DiscountStrategy(r.Off)
+		} else {
+			byName[r.Name] = WithCoupon(r.Amount)
+		}
+	}
+
+	order := c.Order
+	if len(order) == 0 {
+		for _, r := range c.Rules {
+			order = append(order, r.Name)
+		}
+	}
+
+	strategies := make([]PriceStrategy, 0, len(order))
+	for _, name := range order {
+		strategies = append(strategies, floorAtZero(byName[name]))
+	}
+	return Combine(strategies...), nil
+}
+
+func floorAtZero(s PriceStrategy) PriceStrategy {
+	return func(price float64) float64 {
+		return math.Max(s(price), 0)
+	}
+}
+
+// ParsePricingConfig decodes JSON or, for format "yaml", the YAML subset described above.
+func ParsePricingConfig(data []byte, format string) (PricingConfig, error) {
+	var c PricingConfig
+	switch format {
+	case "json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err := dec.Decode(&c)
+		return c, err
+	case "yaml":
+		return parseYAMLPricing(data)
+	default:
+		return c, fmt.Errorf("unsupported pricing config format %q", format)
+	}
+}
+
+// formatOf picks the parser from the extension; a document starting with "{" is
+// always JSON (which is also valid YAML, just not in our subset).
+func formatOf(path string, data []byte) string {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		return "json"
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return "yaml"
+	default:
+		return "json"
+	}
+}
+
+func LoadPricingRules(path string) (PriceStrategy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := ParsePricingConfig(data, formatOf(path, data))
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return c.Build()
+}
+
+// parseYAMLPricing understands only what a rule file needs: the top-level keys
+// "rules" (a list of flat maps) and "order" (a list of scalars), plus # comments.
+func parseYAMLPricing(data []byte) (PricingConfig, error) {
+	var c PricingConfig
+	var section string
+	var rule map[string]string
+
+	flush := func() error {
+		if rule == nil {
+			return nil
+		}
+		r, err := ruleFromFields(rule)
+		if err != nil {
+			return err
+		}
+		c.Rules = append(c.Rules, r)
+		rule = nil
+		return nil
+	}
+
+	for i, line := range strings.Split(string(data), "\n") {
+		lineNo := i + 1
+		line = stripComment(line)
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		indented := line[0] == ' ' || line[0] == '\t'
+		text := strings.TrimSpace(line)
+
+		if !indented {
+			if err := flush(); err != nil {
+				return c, err
+			}
+			key, rest, ok := strings.Cut(text, ":")
+			if !ok || strings.TrimSpace(rest) != "" || (key != "rules" && key != "order") {
+				return c, fmt.Errorf("line %d: expected \"rules:\" or \"order:\"", lineNo)
+			}
+			section = key
+			continue
+		}
+
+		item, isItem := strings.CutPrefix(text, "- ")
+		switch section {
+		case "order":
+			if !isItem {
+				return c, fmt.Errorf("line %d: expected a list item under order", lineNo)
+			}
+			c.Order = append(c.Order, unquote(item))
+		case "rules":
+			if isItem {
+				if err := flush(); err != nil {
+					return c, err
+				}
+				rule = map[string]string{}
+				text = item
+			} else if rule == nil {
+				return c, fmt.Errorf("line %d: expected \"- \" to start a rule", lineNo)
+			}
+			key, value, ok := strings.Cut(text, ":")
+			if !ok {
+				return c, fmt.Errorf("line %d: expected key: value", lineNo)
+			}
+			rule[strings.TrimSpace(key)] = unquote(strings.TrimSpace(value))
+		default:
+			return c, fmt.Errorf("line %d: indented line outside of rules/order", lineNo)
+		}
+	}
+
+	return c, flush()
+}
+
+func ruleFromFields(fields map[string]string) (PricingRule, error) {
+	r := PricingRule{Name: fields["name"], Type: fields["type"]}
+	for key, value := range fields {
+		switch key {
+		case "name", "type":
+		case "off", "amount":
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return r, &RuleConfigError{Rule: r.Name, Msg: fmt.Sprintf("%s: %v", key, err)}
+			}
+			if key == "off" {
+				r.Off = v
+			} else {
+				r.Amount = v
+			}
+		default:
+			return r, &RuleConfigError{Rule: r.Name, Msg: fmt.Sprintf("unknown field %q", key)}
+		}
+	}
+	return r, nil
+}
+
+// stripComment cuts a # comment off line. As in YAML, # starts a comment only at the
+// beginning of the line or after whitespace, and never inside a quoted value, so
+// name: "black#friday" and name: sale#2 keep their #.
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// PricingWatcher reloads a rule file when it changes. A file that fails to load
+// is reported and the last good strategy stays in effect.
+type PricingWatcher struct {
+	path    string
+	mu      sync.RWMutex
+	current PriceStrategy
+	modTime time.Time
+	size    int64
+	onError func(error)
+	onLoad  func()
+}
+
+// WatchPricingRules loads path and then polls it every interval until ctx is canceled.
+func WatchPricingRules(ctx context.Context, path string, interval time.Duration, onError func(error)) (*PricingWatcher, error) {
+	w := &PricingWatcher{path: path, onError: onError}
+	if err := w.reload(); err != nil {
+		return nil, err
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				w.poll()
+			}
+		}
+	}()
+
+	return w, nil
+}
+
+// OnReload sets a function called after every successful reload.
+func (w *PricingWatcher) OnReload(fn func()) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.onLoad = fn
+}
+
+// Current returns the strategy built from the latest valid rule file.
+func (w *PricingWatcher) Current() PriceStrategy {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	return w.current
+}
+
+func (w *PricingWatcher) poll() {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		w.report(err)
+		return
+	}
+
+	w.mu.RLock()
+	changed := !info.ModTime().Equal(w.modTime) || info.Size() != w.size
+	w.mu.RUnlock()
+
+	if changed {
+		if err := w.reload(); err != nil {
+			w.report(err)
+		}
+	}
+}
+
+func (w *PricingWatcher) reload() error {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return err
+	}
+
+	strategy, err := LoadPricingRules(w.path)
+
+	w.mu.Lock()
+	// remember the version even if it's invalid, so a bad file is reported once, not every poll
+	w.modTime, w.size = info.ModTime(), info.Size()
+	if err == nil {
+		w.current = strategy
+	}
+	onLoad := w.onLoad
+	w.mu.Unlock()
+
+	if err == nil && onLoad != nil {
+		onLoad()
+	}
+	return err
+}
+
+func (w *PricingWatcher) report(err error) {
+	if w.onError != nil {
+		w.onError(err)
+		return
+	}
+	fmt.Println("pricing rules reload failed:", err)
+}
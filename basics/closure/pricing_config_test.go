@@ -0,0 +1,62 @@
+package closure
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildNeverGoesNegative(t *testing.T) {
+	c := PricingConfig{Rules: []PricingRule{
+		{Name: "sale", Type: "discount", Off: 0.5},
+		{Name: "big-coupon", Type: "coupon", Amount: 80},
+	}}
+	strategy, err := c.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strategy(100); got != 0 {
+		t.Errorf("price = %v, want 0", got)
+	}
+}
+
+func TestValidateRejectsDuplicateOrder(t *testing.T) {
+	c := PricingConfig{
+		Rules: []PricingRule{{Name: "welcome", Type: "coupon", Amount: 5}},
+		Order: []string{"welcome", "welcome"},
+	}
+	err := c.Validate()
+	if err == nil || !strings.Contains(err.Error(), "more than once") {
+		t.Errorf("Validate() = %v, want a duplicate order error", err)
+	}
+}
+
+func TestYAMLComments(t *testing.T) {
+	data := `# pricing rules
+rules:  # the list
+  - name: "black#friday"   # quoted, so the # is part of the name
+    type: coupon
+    amount: 10
+  - name: sale#2
+    type: 'discount'
+    off: 0.25 #a quarter
+order:
+  - 'black#friday' # first
+  - sale#2
+`
+	c, err := ParsePricingConfig([]byte(data), "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Rules) != 2 || c.Rules[0].Name != "black#friday" || c.Rules[1].Name != "sale#2" {
+		t.Fatalf("rules = %+v, want black#friday and sale#2", c.Rules)
+	}
+	if c.Rules[0].Amount != 10 || c.Rules[1].Type != "discount" || c.Rules[1].Off != 0.25 {
+		t.Errorf("rules = %+v", c.Rules)
+	}
+	if len(c.Order) != 2 || c.Order[0] != "black#friday" || c.Order[1] != "sale#2" {
+		t.Errorf("order = %q, want [black#friday sale#2]", c.Order)
+	}
+	if err := c.Validate(); err != nil {
+		t.Errorf("Validate() = %v", err)
+	}
+}
@@ -0,0 +1,29 @@
+package closure
+
+import "testing"
+
+func TestBuyXGetYRejectsBadCounts(t *testing.T) {
+	for _, c := range [][2]int{{0, 0}, {2, 0}, {0, 1}, {-1, 2}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("BuyXGetY(%d, %d) did not panic", c[0], c[1])
+				}
+			}()
+			BuyXGetY("socks", c[0], c[1])
+		}()
+	}
+}
+
+func TestGroupWinnerWithDuplicateNames(t *testing.T) {
+	e := NewPricingEngine()
+	e.Add(
+		Promotion{Name: "promo", Strategy: Price(WithCoupon(10)), Group: "g"},
+		Promotion{Name: "promo", Strategy: Price(WithCoupon(30)), Group: "g"},
+	)
+
+	q := e.Quote(Cart{Items: []LineItem{{SKU: "a", UnitPrice: 100, Quantity: 1}}})
+	if q.Total != 70 {
+		t.Errorf("total = %v, want 70 (only the $30 coupon applies)", q.Total)
+	}
+}
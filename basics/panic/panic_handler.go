@@ -2,20 +2,54 @@ package panic
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"runtime/debug"
 )
 
-func ProcessFile(path string) (err error) {
+// PanicError is what a recovered panic turns into: the value passed to panic()
+// plus the stack of the goroutine at the time, so the failure can still be debugged.
+type PanicError struct {
+	Path  string // the file being processed, empty for a panic outside file processing
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("goroutine panicked: %v", e.Value)
+	}
+	return fmt.Sprintf("error processing file %s: %v", e.Path, e.Value)
+}
+
+// Unwrap exposes the panic value when it was an error, e.g. a runtime.Error.
+func (e *PanicError) Unwrap() error {
+	if err, ok := e.Value.(error); ok {
+		return err
+	}
+	return nil
+}
+
+// Parser reads a file's content; name is only used for error messages.
+type Parser[T any] func(name string, r io.Reader) (T, error)
+
+func ProcessFile(path string) error {
+	_, err := ProcessFileWith(path, parseFile)
+	return err
+}
+
+// ProcessFileWith opens path and runs parse on it, turning a panic in parse into a *PanicError.
+func ProcessFileWith[T any](path string, parse Parser[T]) (result T, err error) {
 	// Defer a function to handle any panic that occurs
 	defer func() {
 		if r := recover(); r != nil {
-			err = fmt.Errorf("error processing file %s: %v", path, r)
+			err = &PanicError{Path: path, Value: r, Stack: debug.Stack()}
 		}
 	}()
 
 	file, err := os.Open(path)
 	if err != nil {
-		return err
+		return result, err
 	}
 
 	// Ensure the file is closed after processing
@@ -24,12 +58,10 @@ func ProcessFile(path string) (err error) {
 		file.Close()
 	}()
 
-	parseFile(file)
-
-	return nil
+	return parse(path, file)
 }
 
-func parseFile(f *os.File) {
-	// Simulate a panic during parsing
-	panic("simulated parsing error")
+// parseFile checks that the file is a valid key=value record file, stopping at the first error.
+func parseFile(name string, r io.Reader) (ParseSummary, error) {
+	return RecordParser{Mode: Strict}.Parse(name, r, nil)
 }
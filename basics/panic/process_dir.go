@@ -0,0 +1,91 @@
+package panic
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// ProcessDir runs a parser over every regular file in a directory (not recursive)
+// with a fixed number of workers. Each file is processed by ProcessFileWith, so a
+// parser that panics on one file only fails that file; the others carry on.
+
+type FileResult[T any] struct {
+	Path  string
+	Value T
+	Err   error
+}
+
+// Panicked reports whether the file failed because the parser panicked.
+func (r FileResult[T]) Panicked() bool {
+	var pe *PanicError
+	return errors.As(r.Err, &pe)
+}
+
+type Report[T any] struct {
+	Succeeded []FileResult[T]
+	Failed    []FileResult[T]
+}
+
+func ProcessDir[T any](ctx context.Context, dir string, workers int, parse Parser[T]) (Report[T], error) {
+	var report Report[T]
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return report, err
+	}
+
+	paths := make(chan string)
+	results := make(chan FileResult[T])
+	var wg sync.WaitGroup
+
+	for range max(workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range paths {
+				value, err := ProcessFileWith(path, parse)
+				results <- FileResult[T]{Path: path, Value: value, Err: err}
+			}
+		}()
+	}
+
+	// Submit files until done or canceled
+	go func() {
+		defer close(paths)
+		for _, e := range entries {
+			if !e.Type().IsRegular() {
+				continue
+			}
+			select {
+			case paths <- filepath.Join(dir, e.Name()):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	for r := range results {
+		if r.Err != nil {
+			report.Failed = append(report.Failed, r)
+		} else {
+			report.Succeeded = append(report.Succeeded, r)
+		}
+	}
+
+	byPath := func(rs []FileResult[T]) {
+		sort.Slice(rs, func(i, j int) bool { return rs[i].Path < rs[j].Path })
+	}
+	byPath(report.Succeeded)
+	byPath(report.Failed)
+
+	return report, ctx.Err()
+}
@@ -0,0 +1,106 @@
+package panic
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestProcessDir(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"a.txt": "id=1 name=Alice\nid=2 name=Bob\n",
+		"b.txt": "id=3\nbroken\n",
+		"c.txt": "# nothing but a comment\n",
+	})
+	os.Mkdir(filepath.Join(dir, "sub"), 0o755) // directories are skipped
+
+	report, err := ProcessDir(context.Background(), dir, 2, RecordParser{}.Parser(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Succeeded) != 2 || len(report.Failed) != 1 {
+		t.Fatalf("report = %d succeeded, %d failed; want 2 and 1", len(report.Succeeded), len(report.Failed))
+	}
+	if got := report.Succeeded[0]; filepath.Base(got.Path) != "a.txt" || got.Value.Records != 2 {
+		t.Errorf("first success = %+v, want a.txt with 2 records", got)
+	}
+	failed := report.Failed[0]
+	var perr *ParseError
+	if !errors.As(failed.Err, &perr) || perr.Line != 2 || failed.Panicked() {
+		t.Errorf("failure = %v, want a parse error on line 2, not a panic", failed.Err)
+	}
+}
+
+func TestProcessDirRecoversPanics(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"good.txt":   "ok",
+		"nil.txt":    "nil map",
+		"string.txt": "panic",
+	})
+	parse := func(name string, r io.Reader) (int, error) {
+		data, _ := io.ReadAll(r)
+		switch string(data) {
+		case "nil map":
+			var m map[string]int
+			m["boom"] = 1 // a runtime error
+		case "panic":
+			panic("parser gave up")
+		}
+		return len(data), nil
+	}
+
+	report, err := ProcessDir(context.Background(), dir, 3, parse)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Succeeded) != 1 || len(report.Failed) != 2 {
+		t.Fatalf("report = %d succeeded, %d failed; want 1 and 2", len(report.Succeeded), len(report.Failed))
+	}
+
+	for _, r := range report.Failed {
+		var pe *PanicError
+		if !r.Panicked() || !errors.As(r.Err, &pe) {
+			t.Fatalf("%s: %v, want a *PanicError", r.Path, r.Err)
+		}
+		if pe.Path != r.Path || len(pe.Stack) == 0 {
+			t.Errorf("%s: PanicError path %q, %d bytes of stack", r.Path, pe.Path, len(pe.Stack))
+		}
+	}
+
+	// a runtime error stays reachable through Unwrap, a plain value doesn't
+	var rerr runtime.Error
+	if nilMap := report.Failed[0]; !errors.As(nilMap.Err, &rerr) {
+		t.Errorf("%s: %v, want a runtime.Error inside", nilMap.Path, nilMap.Err)
+	}
+	if str := report.Failed[1]; errors.Unwrap(str.Err) != nil {
+		t.Errorf("%s: Unwrap = %v, want nil for a string panic", str.Path, errors.Unwrap(str.Err))
+	}
+}
+
+func TestProcessDirCanceled(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"a.txt": "id=1\n"})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := ProcessDir(ctx, dir, 1, RecordParser{}.Parser(nil)); !errors.Is(err, context.Canceled) {
+		t.Errorf("ProcessDir = %v, want context.Canceled", err)
+	}
+	if _, err := ProcessDir(context.Background(), filepath.Join(dir, "missing"), 1, RecordParser{}.Parser(nil)); err == nil {
+		t.Error("ProcessDir of a missing directory succeeded")
+	}
+}
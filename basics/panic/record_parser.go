@@ -0,0 +1,221 @@
+package panic
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// A streaming parser for a line-oriented key=value record format:
+//
+// # comments and blank lines are skipped
+// id=1 name=Alice city="New York"
+// id=2 name=Bob note="says \"hi\""
+//
+// Each line is one record of whitespace-separated key=value fields; values with
+// spaces are double-quoted, with \" and \\ as escapes. The input is read line by
+// line and each record is handed to a callback, so the file is never held in memory.
+//
+// Strict mode stops at the first error. Lenient mode skips bad lines and collects
+// their errors, giving up once more than MaxErrors have been seen.
+
+type ParseMode int
+
+const (
+	Strict ParseMode = iota
+	Lenient
+)
+
+// maxLineSize bounds the memory used for one line.
+const maxLineSize = 1 << 20
+
+var ErrTooManyErrors = errors.New("too many parse errors")
+
+// ParseError points at the offending spot; Column counts characters (runes), starting at 1.
+type ParseError struct {
+	File   string
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Msg)
+}
+
+type Field struct {
+	Key, Value string
+}
+
+type Record struct {
+	Line   int
+	Fields []Field // in file order
+}
+
+func (r Record) Get(key string) (string, bool) {
+	for _, f := range r.Fields {
+		if f.Key == key {
+			return f.Value, true
+		}
+	}
+	return "", false
+}
+
+type RecordParser struct {
+	Mode      ParseMode
+	MaxErrors int // lenient mode only; 0 means no limit
+}
+
+type ParseSummary struct {
+	Records int
+	Errors  []*ParseError // lenient mode: the lines that were skipped
+}
+
+// Parse reads records from r and calls handle for each one; an error from handle stops parsing.
+func (p RecordParser) Parse(name string, r io.Reader, handle func(Record) error) (ParseSummary, error) {
+	var summary ParseSummary
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+
+		fields, perr := parseLine(scanner.Bytes())
+		if perr != nil {
+			perr.File, perr.Line = name, lineNo
+			if p.Mode == Strict {
+				return summary, perr
+			}
+			summary.Errors = append(summary.Errors, perr)
+			if p.MaxErrors > 0 && len(summary.Errors) > p.MaxErrors {
+				return summary, fmt.Errorf("%w: more than %d in %s", ErrTooManyErrors, p.MaxErrors, name)
+			}
+			continue
+		}
+		if fields == nil {
+			continue // blank or comment
+		}
+
+		summary.Records++
+		if handle != nil {
+			if err := handle(Record{Line: lineNo, Fields: fields}); err != nil {
+				return summary, err
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return summary, &ParseError{File: name, Line: lineNo + 1, Column: 1, Msg: fmt.Sprintf("line longer than %d bytes", maxLineSize)}
+		}
+		return summary, err
+	}
+	return summary, nil
+}
+
+// Parser adapts p to ProcessFileWith.
+func (p RecordParser) Parser(handle func(Record) error) Parser[ParseSummary] {
+	return func(name string, r io.Reader) (ParseSummary, error) {
+		return p.Parse(name, r, handle)
+	}
+}
+
+// parseLine returns nil fields for a blank or comment line. The returned error
+// has Column set; the caller fills in File and Line.
+func parseLine(line []byte) ([]Field, *ParseError) {
+	fail := func(at int, format string, args ...any) ([]Field, *ParseError) {
+		return nil, &ParseError{Column: utf8.RuneCount(line[:at]) + 1, Msg: fmt.Sprintf(format, args...)}
+	}
+
+	i := skipSpace(line, 0)
+	if i == len(line) || line[i] == '#' {
+		return nil, nil
+	}
+
+	var fields []Field
+	seen := map[string]bool{}
+
+	for i < len(line) {
+		keyStart := i
+		for i < len(line) && isKeyByte(line[i]) {
+			i++
+		}
+		if i == keyStart {
+			r, _ := utf8.DecodeRune(line[i:])
+			return fail(i, "expected a key, found %q", r)
+		}
+		key := string(line[keyStart:i])
+		if seen[key] {
+			return fail(keyStart, "duplicate key %q", key)
+		}
+		seen[key] = true
+
+		if i == len(line) || line[i] != '=' {
+			return fail(i, "expected '=' after key %q", key)
+		}
+		i++
+
+		var value string
+		if i < len(line) && line[i] == '"' {
+			quote := i
+			var buf []byte
+			i++
+			for {
+				if i == len(line) {
+					return fail(quote, "unterminated quoted value for key %q", key)
+				}
+				c := line[i]
+				if c == '"' {
+					i++
+					break
+				}
+				if c == '\\' {
+					if i+1 == len(line) || (line[i+1] != '"' && line[i+1] != '\\') {
+						return fail(i, "invalid escape in value for key %q", key)
+					}
+					i++
+					c = line[i]
+				}
+				buf = append(buf, c)
+				i++
+			}
+			value = string(buf)
+		} else {
+			start := i
+			for i < len(line) && !isSpace(line[i]) {
+				if line[i] == '"' {
+					return fail(i, "unexpected '\"' in unquoted value for key %q", key)
+				}
+				i++
+			}
+			value = string(line[start:i])
+		}
+
+		if i < len(line) && !isSpace(line[i]) {
+			return fail(i, "expected whitespace after value for key %q", key)
+		}
+		fields = append(fields, Field{Key: key, Value: value})
+		i = skipSpace(line, i)
+	}
+
+	return fields, nil
+}
+
+func isKeyByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-' || c == '.'
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r'
+}
+
+func skipSpace(line []byte, i int) int {
+	for i < len(line) && isSpace(line[i]) {
+		i++
+	}
+	return i
+}
@@ -0,0 +1,112 @@
+package panic
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseRecords(t *testing.T) {
+	input := `# people
+id=1 name=Alice city="New York"
+
+	id=2 name=Bob note="says \"hi\" \\ bye"   
+id=3 name=Zoë empty=""
+`
+	var got []Record
+	summary, err := RecordParser{}.Parse("people.txt", strings.NewReader(input), func(r Record) error {
+		got = append(got, r)
+		return nil
+	})
+	if err != nil || summary.Records != 3 {
+		t.Fatalf("Parse = %+v, %v; want 3 records", summary, err)
+	}
+
+	want := []Record{
+		{Line: 2, Fields: []Field{{"id", "1"}, {"name", "Alice"}, {"city", "New York"}}},
+		{Line: 4, Fields: []Field{{"id", "2"}, {"name", "Bob"}, {"note", `says "hi" \ bye`}}},
+		{Line: 5, Fields: []Field{{"id", "3"}, {"name", "Zoë"}, {"empty", ""}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("records = %+v\nwant %+v", got, want)
+	}
+	if v, ok := got[0].Get("city"); !ok || v != "New York" {
+		t.Errorf("Get(city) = %q, %v", v, ok)
+	}
+	if _, ok := got[0].Get("note"); ok {
+		t.Error("Get of a missing key succeeded")
+	}
+}
+
+func TestParseErrorPositions(t *testing.T) {
+	tests := []struct {
+		line   string
+		column int
+		msg    string
+	}{
+		{`=1`, 1, "expected a key"},
+		{`id=1 é=2`, 6, `found 'é'`}, // a multi-byte rune is reported whole
+		{`name="Zoë" =2`, 12, "expected a key"},
+		{`id=1 id=2`, 6, `duplicate key "id"`},
+		{`id`, 3, `expected '=' after key "id"`},
+		{`id:1`, 3, `expected '=' after key "id"`},
+		{`name="Alice`, 6, "unterminated quoted value"},
+		{`note="a\b"`, 8, "invalid escape"},
+		{`note="ab\`, 9, "invalid escape"},
+		{`name=Al"ice`, 8, `unexpected '"'`},
+		{`name="Al"ice`, 10, "expected whitespace after value"},
+		{`name="Zoë"x`, 11, "expected whitespace after value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			_, err := RecordParser{}.Parse("bad.txt", strings.NewReader("id=0\n"+tt.line+"\n"), nil)
+			var perr *ParseError
+			if !errors.As(err, &perr) {
+				t.Fatalf("Parse = %v, want a *ParseError", err)
+			}
+			if perr.File != "bad.txt" || perr.Line != 2 || perr.Column != tt.column || !strings.Contains(perr.Msg, tt.msg) {
+				t.Errorf("error = %v, want bad.txt:2:%d: ...%s...", perr, tt.column, tt.msg)
+			}
+		})
+	}
+}
+
+func TestParseLenient(t *testing.T) {
+	input := "id=1\nbad\nid=2\n=oops\nid=3\n"
+
+	summary, err := RecordParser{Mode: Lenient}.Parse("mixed.txt", strings.NewReader(input), nil)
+	if err != nil || summary.Records != 3 || len(summary.Errors) != 2 {
+		t.Fatalf("Parse = %+v, %v; want 3 records and 2 errors", summary, err)
+	}
+	if summary.Errors[0].Line != 2 || summary.Errors[1].Line != 4 {
+		t.Errorf("error lines = %d, %d; want 2, 4", summary.Errors[0].Line, summary.Errors[1].Line)
+	}
+
+	_, err = RecordParser{Mode: Lenient, MaxErrors: 1}.Parse("mixed.txt", strings.NewReader(input), nil)
+	if !errors.Is(err, ErrTooManyErrors) {
+		t.Errorf("Parse with MaxErrors 1 = %v, want ErrTooManyErrors", err)
+	}
+}
+
+func TestParseStopsOnHandlerError(t *testing.T) {
+	stop := errors.New("stop")
+	summary, err := RecordParser{}.Parse("x", strings.NewReader("id=1\nid=2\nid=3\n"), func(r Record) error {
+		if r.Line == 2 {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) || summary.Records != 2 {
+		t.Errorf("Parse = %+v, %v; want 2 records and the handler's error", summary, err)
+	}
+}
+
+func TestParseLineTooLong(t *testing.T) {
+	input := "id=1\nv=" + strings.Repeat("x", maxLineSize) + "\n"
+	_, err := RecordParser{}.Parse("long.txt", strings.NewReader(input), nil)
+	var perr *ParseError
+	if !errors.As(err, &perr) || perr.Line != 2 {
+		t.Errorf("Parse = %v, want a *ParseError on line 2", err)
+	}
+}
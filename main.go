@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	concurrency "go-practice/advanced/concurrency"
 	goroutine "go-practice/advanced/goroutine"
@@ -11,9 +13,52 @@ import (
 	panic "go-practice/basics/panic"
 	student "go-practice/basics/student"
 	std "go-practice/std"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
 func main() {
+	// Subcommand: `go run . serve [addr]` starts the banking API instead of running the examples
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		addr := ":8080"
+		if len(os.Args) > 2 {
+			addr = os.Args[2]
+		}
+		fmt.Println("Banking API listening on", addr)
+		if err := http.ListenAndServe(addr, account.NewServer(account.NewManager())); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Subcommand: `go run . replay <dir> <offset>` writes outbox events from an offset to stdout
+	// as JSON lines, for piping into whatever re-publishes them
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		if len(os.Args) != 4 {
+			fmt.Fprintln(os.Stderr, "usage: replay <outbox dir> <offset>")
+			os.Exit(2)
+		}
+		from, err := strconv.ParseInt(os.Args[3], 10, 64)
+		if err == nil {
+			var n int
+			n, err = interface_example.ReplayOutbox(context.Background(), os.Args[2], from,
+				interface_example.JSONLinesSender(os.Stdout))
+			fmt.Fprintf(os.Stderr, "replayed %d event(s) from offset %d\n", n, from)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Calculator
 	calc := calculator.NewCalculator()
 	sum := calc.Add(10, 5)
@@ -135,12 +180,124 @@ func main() {
 		fmt.Printf("Withdrew $100 from %s's account. New Balance: $%.2f\n", acc2.Name, balance)
 	}
 
+	// Durable Account Store - WAL + snapshot, recovered on reopen
+	storeDir, err := os.MkdirTemp("", "account-store")
+	if err != nil {
+		fmt.Println(err)
+	} else {
+		defer os.RemoveAll(storeDir)
+
+		store, err := account.OpenStore(storeDir, 2)
+		if err != nil {
+			fmt.Println(err)
+		} else {
+			acc, _ := store.OpenAccount("Durable Dan")
+			store.Deposit(acc.ID, 300)
+			store.WithDraw(acc.ID, 120)
+			store.Close()
+
+			// reopen: snapshot + WAL replay restores the balance and the ID sequence
+			store, err = account.OpenStore(storeDir, 2)
+			if err != nil {
+				fmt.Println(err)
+			} else {
+				balance, _ := store.GetBalance(acc.ID)
+				next, _ := store.OpenAccount("Durable Dora")
+				fmt.Printf("Recovered %s's balance: $%.2f, next account ID: %d\n", acc.Name, balance, next.ID)
+				store.Close()
+			}
+		}
+	}
+
+	// Account Interest and Scheduled Jobs - fast-forward a fake clock through January
+	clock := account.NewFakeClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	savings := account.NewManager()
+	savings.SetClock(clock)
+	saver := savings.OpenAccount("Saver Sam")
+	checking := savings.OpenAccount("Checking Sam")
+	savings.Deposit(checking.ID, 1000)
+
+	interest := account.NewInterestEngine(savings, clock)
+	interest.SetPlan(saver.ID, account.InterestPlan{AnnualRate: 0.12, Kind: account.CompoundInterest})
+
+	scheduler := account.NewScheduler(clock)
+	scheduler.ScheduleInterest(interest)
+	scheduler.ScheduleTransfer(savings, checking.ID, saver.ID, 100, 7*24*time.Hour) // weekly standing order
+	for range 31 {
+		clock.BlockUntil(2) // both jobs are waiting for their next tick
+		clock.Advance(24 * time.Hour)
+	}
+	clock.BlockUntil(2)
+	scheduler.Stop()
+	scheduler.Wait()
+
+	saverBalance, _ := savings.GetBalance(saver.ID)
+	checkingBalance, _ := savings.GetBalance(checking.ID)
+	fmt.Printf("On %s: %s has $%.2f (interest posted), %s has $%.2f\n",
+		clock.Now().Format("2006-01-02"), saver.Name, saverBalance, checking.Name, checkingBalance)
+
+	// Fraud and Velocity Rules - checked before every withdrawal
+	blocklist := account.NewBlocklistRule()
+	rules := account.NewRuleEngine(account.RealClock(),
+		blocklist,
+		&account.VelocityRule{MaxCount: 2, Window: time.Hour},
+		&account.LargeTransactionRule{Threshold: 500},
+	)
+	savings.SetRules(rules)
+
+	fmt.Println("Withdraw $50:", savings.WithDraw(checking.ID, 50))
+	err = savings.WithDraw(checking.ID, 550) // held until someone approves it
+	fmt.Println("Withdraw $550:", err)
+	var held *account.RuleError
+	if errors.As(err, &held) {
+		fmt.Println("Approve hold:", savings.Approve(held.HoldID))
+	}
+	fmt.Println("Withdraw $1:", savings.WithDraw(checking.ID, 1)) // third withdrawal in an hour
+	fmt.Println("Transfer $5:", savings.Transfer(checking.ID, saver.ID, 5))
+	blocklist.Block(saver.ID, "reported stolen card")
+	if err := savings.WithDraw(saver.ID, 10); err != nil {
+		var ruleErr *account.RuleError
+		if errors.As(err, &ruleErr) {
+			fmt.Printf("Denied by %q: %s\n", ruleErr.Rule, ruleErr.Reason)
+		}
+	}
+	for _, d := range rules.Flagged() {
+		fmt.Printf("Flagged for review: account %d $%.2f by %s (%s)\n", d.AccountID, d.Amount, d.Rule, d.Reason)
+	}
+
 	// Closure Examples 1 - Counter
 	counter := closure.NewCounter()
 	fmt.Println("Counter:", counter()) // 1
 	fmt.Println("Counter:", counter()) // 2
 	fmt.Println("Counter:", counter()) // 3
 
+	// Closure Examples 1b - Counter family with options, reset and peek
+	next, reset, peek := closure.MakeCounter(closure.WithStart(10), closure.WithStep(5), closure.WithMax(20, closure.Wrap))
+	for range 3 {
+		v, _ := next()
+		fmt.Println("Wrapping counter:", v) // 15, 20, 10
+	}
+	reset()
+	fmt.Println("Wrapping counter after reset:", peek())
+
+	limited, _, _ := closure.MakeCounter(closure.WithMax(1, closure.Error))
+	limited()
+	if _, err := limited(); err != nil {
+		fmt.Println("Limited counter:", err)
+	}
+
+	hits := closure.NewCounterSet()
+	var hitsWG sync.WaitGroup
+	for i := range 100 {
+		hitsWG.Add(1)
+		go func(id int) {
+			defer hitsWG.Done()
+			hits.Inc([]string{"/home", "/login"}[id%2])
+		}(i)
+	}
+	hitsWG.Wait()
+	fmt.Println("Requests per route:", hits.Snapshot())
+
 	// Closure Examples 2 - UI Handlers
 	btnA := closure.Button{Label: "Button A"}
 	btnB := closure.Button{Label: "Button B"}
@@ -157,6 +314,57 @@ func main() {
 	btnA.OnClick(btnAHandler.Handle)
 	btnB.OnClick(btnBHandler)
 
+	// Closure Examples 2b - Event listeners with tokens, propagation stopping and async dispatch
+	btnC := closure.Button{Label: "Button C"}
+	logToken := btnC.On(closure.Click, func(e *closure.Event) {
+		fmt.Printf("[log] %s %s at %s (modifiers: %v)\n", e.Source, e.Type, e.Time.Format("15:04:05.000"), e.Modifiers)
+	})
+	btnC.On(closure.Click, func(e *closure.Event) {
+		if e.Modifiers&closure.Shift != 0 {
+			fmt.Println("[guard] Shift-click handled here, stop propagation")
+			e.StopPropagation()
+		}
+	})
+	btnC.On(closure.Click, func(e *closure.Event) {
+		var clicks map[string]int
+		clicks[e.Source]++ // buggy listener: panics on a nil map, isolated from the others
+	})
+	btnC.On(closure.Click, func(e *closure.Event) {
+		fmt.Println("[submit] form submitted")
+	})
+
+	btnC.Click(0)
+	btnC.Click(closure.Shift | closure.Ctrl)
+	btnC.Unsubscribe(logToken)
+	btnC.SetAsync(true)
+	btnC.Click(0)
+	btnC.Wait()
+
+	// Closure Examples 2c - Middleware chains shared by plain handlers and listeners
+	counting, calls := closure.Counting[struct{}]()
+	guarded := closure.WrapFunc(closure.MakeHandler("HandlerD"),
+		closure.Recover[struct{}](nil),
+		counting,
+		closure.Throttle[struct{}](50*time.Millisecond),
+	)
+	for range 5 {
+		btnA.OnClick(guarded) // only the first click inside 50ms gets through the throttle
+	}
+	fmt.Println("Clicks that reached the throttle:", calls())
+
+	onlyOnce := closure.WrapFunc(func() { fmt.Println("Initialized once") }, closure.Once[struct{}]())
+	onlyOnce()
+	onlyOnce()
+
+	btnD := closure.Button{Label: "Button D"}
+	btnD.On(closure.Click, closure.WrapListener(func(e *closure.Event) {
+		fmt.Println("Debounced click from", e.Source)
+	}, closure.Debounce[*closure.Event](20*time.Millisecond), closure.Logging[*closure.Event]("debounced-click")))
+	for range 3 {
+		btnD.Click(0) // three quick clicks collapse into one call
+	}
+	time.Sleep(50 * time.Millisecond)
+
 	// Closure Examples 3 - Price Strategies
 	originalPrice := 100.0
 
@@ -165,12 +373,159 @@ func main() {
 	final := closure.Combine(discount, coupon)
 	fmt.Printf("Original Price: $%.2f, Final Price after strategies: $%.2f\n", originalPrice, final(originalPrice))
 
+	// Closure Examples 3b - Pricing engine with conditions, stacking rules, guards and a trace
+	checkoutTime := time.Date(2026, 11, 27, 10, 0, 0, 0, time.UTC)
+	pricing := closure.NewPricingEngine(closure.WithFloor(0))
+	pricing.Add(
+		closure.Promotion{Name: "socks 3-for-2", Strategy: closure.BuyXGetY("socks", 2, 1), Priority: 1},
+		closure.Promotion{Name: "bulk tees", Strategy: closure.QuantityTiers("tee", closure.QuantityTier{MinQuantity: 3, Off: 0.1}, closure.QuantityTier{MinQuantity: 5, Off: 0.2}), Priority: 1},
+		closure.Promotion{Name: "10% over $100", Strategy: closure.Price(closure.DiscountStrategy(0.10)), When: closure.MinSpend(100), Priority: 2, Group: "order-discount"},
+		closure.Promotion{Name: "black friday 15%", Strategy: closure.Price(closure.DiscountStrategy(0.15)), Priority: 2, Group: "order-discount",
+			When: closure.TimeWindow(checkoutTime.Add(-24*time.Hour), checkoutTime.Add(24*time.Hour))},
+		closure.Promotion{Name: "staff $20 off", Strategy: closure.Price(closure.WithCoupon(20)), Exclusive: true},
+		closure.Promotion{Name: "$5 coupon", Strategy: closure.Price(closure.WithCoupon(5)), Priority: 3},
+		closure.Promotion{Name: "VIP night", Strategy: closure.Price(closure.DiscountStrategy(0.5)), When: closure.TimeWindow(checkoutTime.Add(12*time.Hour), checkoutTime.Add(14*time.Hour))},
+	)
+	quote := pricing.Quote(closure.Cart{
+		Time: checkoutTime,
+		Items: []closure.LineItem{
+			{SKU: "socks", UnitPrice: 5, Quantity: 6},
+			{SKU: "tee", UnitPrice: 20, Quantity: 5},
+		},
+	})
+	fmt.Printf("Subtotal: $%.2f\n", quote.Subtotal)
+	for _, adj := range quote.Trace {
+		fmt.Println("  ", adj)
+	}
+	fmt.Printf("Total: $%.2f\n", quote.Total)
+
+	// Closure Examples 3c - Pricing rules loaded from a config file, reloaded when it changes
+	rulesDir, err := os.MkdirTemp("", "pricing-rules")
+	if err != nil {
+		fmt.Println(err)
+	} else {
+		defer os.RemoveAll(rulesDir)
+
+		rulesPath := filepath.Join(rulesDir, "rules.yaml")
+		os.WriteFile(rulesPath, []byte("rules:\n  - name: spring-sale\n    type: discount\n    off: 0.2\n  - name: welcome\n    type: coupon\n    amount: 5\norder:\n  - spring-sale\n  - welcome\n"), 0o644)
+
+		ctx, stopWatching := context.WithCancel(context.Background())
+		reloaded := make(chan struct{}, 1)
+		watcher, err := closure.WatchPricingRules(ctx, rulesPath, 10*time.Millisecond, func(err error) {
+			fmt.Println("Pricing rules rejected:", err)
+			reloaded <- struct{}{}
+		})
+		if err != nil {
+			fmt.Println(err)
+		} else {
+			watcher.OnReload(func() { reloaded <- struct{}{} })
+			fmt.Printf("Configured price of $100: $%.2f\n", watcher.Current()(100))
+
+			// coupon first now, and a bigger one
+			os.WriteFile(rulesPath, []byte(`{"rules":[{"name":"spring-sale","type":"discount","off":0.2},{"name":"welcome","type":"coupon","amount":10}],"order":["welcome","spring-sale"]}`), 0o644)
+			<-reloaded
+			fmt.Printf("Reloaded price of $100: $%.2f\n", watcher.Current()(100))
+
+			// an invalid file is rejected and the last good rules stay in effect
+			os.WriteFile(rulesPath, []byte(`{"rules":[{"name":"too-good","type":"discount","off":1.5},{"name":"mystery","type":"bogo"}]}`), 0o644)
+			<-reloaded
+			fmt.Printf("Price of $100 after a bad edit: $%.2f\n", watcher.Current()(100))
+		}
+		stopWatching()
+	}
+
+	// Closure Examples 4 - Memoization, lazy values, retries and currying
+	config := closure.NewLazy(func() map[string]string {
+		fmt.Println("Loading config (only once)")
+		return map[string]string{"env": "dev"}
+	})
+	var lazyWG sync.WaitGroup
+	for range 3 {
+		lazyWG.Add(1)
+		go func() {
+			defer lazyWG.Done()
+			config.Get()
+		}()
+	}
+	lazyWG.Wait()
+	fmt.Println("Lazy config:", config.Get())
+
+	attempts := 0
+	flaky := closure.Retry(func() error {
+		attempts++
+		if attempts < 3 {
+			return fmt.Errorf("attempt %d failed", attempts)
+		}
+		return nil
+	}, 5, closure.ExponentialBackoff(time.Millisecond, 10*time.Millisecond))
+	fmt.Printf("Retry result: %v after %d attempts\n", flaky(), attempts)
+
+	add := func(a, b int) int { return a + b }
+	add10 := closure.Curry2(add)(10)
+	add100 := closure.Partial(add, 100)
+	fmt.Println("Curried add10(5):", add10(5), "partial add100(5):", add100(5))
+
 	// Handle panic example
 	err3 := panic.ProcessFile("README.md") // adjust the path as needed
 	if err3 != nil {
 		fmt.Println(err3)
 	}
 
+	// Handle panic example 2 - pluggable parser over a whole directory, one panicking file doesn't stop the rest
+	inputDir, err := os.MkdirTemp("", "panic-inputs")
+	if err != nil {
+		fmt.Println(err)
+	} else {
+		defer os.RemoveAll(inputDir)
+
+		os.WriteFile(filepath.Join(inputDir, "a.txt"), []byte("one\ntwo\n"), 0o644)
+		os.WriteFile(filepath.Join(inputDir, "b.txt"), []byte("three\n"), 0o644)
+		os.WriteFile(filepath.Join(inputDir, "c.txt"), []byte(""), 0o644)
+
+		countLines := func(name string, r io.Reader) (int, error) {
+			data, err := io.ReadAll(r)
+			if err != nil {
+				return 0, err
+			}
+			lines := strings.Count(string(data), "\n")
+			_ = 100 / lines // empty file: integer divide by zero panics
+			return lines, nil
+		}
+
+		report, err := panic.ProcessDir(context.Background(), inputDir, 2, countLines)
+		if err != nil {
+			fmt.Println(err)
+		}
+		for _, r := range report.Succeeded {
+			fmt.Printf("OK   %s: %d lines\n", filepath.Base(r.Path), r.Value)
+		}
+		for _, r := range report.Failed {
+			fmt.Printf("FAIL %s: %v (panicked: %v)\n", filepath.Base(r.Path), r.Err, r.Panicked())
+		}
+
+		// Handle panic example 3 - streaming key=value record parser, strict vs lenient
+		recordsPath := filepath.Join(inputDir, "users.records")
+		os.WriteFile(recordsPath, []byte(`# users
+id=1 name=Alice city="New York"
+id=2 name=Bob city="Paris
+id=3 name=Carol note="says \"hi\""
+id=4 name Dan
+`), 0o644)
+
+		fmt.Println(panic.ProcessFile(recordsPath)) // strict: stops at line 3
+
+		lenient := panic.RecordParser{Mode: panic.Lenient, MaxErrors: 5}
+		summary, err := panic.ProcessFileWith(recordsPath, lenient.Parser(func(rec panic.Record) error {
+			name, _ := rec.Get("name")
+			fmt.Printf("record at line %d: name=%s fields=%d\n", rec.Line, name, len(rec.Fields))
+			return nil
+		}))
+		fmt.Printf("Lenient parse: %d records, err=%v\n", summary.Records, err)
+		for _, perr := range summary.Errors {
+			fmt.Println("  skipped:", perr)
+		}
+	}
+
 	// Interface Basis
 	shapes := []interface_example.Shape{
 		interface_example.Rectangle{Width: 10, Height: 5},
@@ -181,6 +536,15 @@ func main() {
 		fmt.Printf("Shape: %T, Area: %.2f, Perimeter: %.2f\n", shape, shape.Area(), shape.Perimeter())
 	}
 
+	// Interface - Geometry with optional interfaces
+	interface_example.GeometryTest()
+
+	// Interface - Shape serialization and SVG rendering
+	interface_example.ShapeFileTest()
+
+	// Interface - Spatial index over shapes
+	interface_example.SpatialIndexTest()
+
 	// Empty Interface - Example 1
 	var i interface_example.EmptyInterface
 
@@ -196,6 +560,9 @@ func main() {
 	i = struct{ Name string }{Name: "Gopher"}
 	fmt.Println(interface_example.PrintValue(i))
 
+	// Empty Interface - Reflection-based pretty printer
+	interface_example.PrettyTest()
+
 	// Empty Interface - Type Assertion and Type Switch
 	interface_example.TypeAssertion("A string value")
 	interface_example.TypeAssertion(100)
@@ -231,15 +598,33 @@ func main() {
 	}
 	fmt.Printf("Parsed User: %+v\n", user)
 
+	// Generic envelope decoding, without the re-marshal round trip
+	interface_example.EnvelopeTest()
+
 	// Outgoing Publishing with unknown payload using empty interface
 	interface_example.Publish("UserCreated", user)
 
+	// Pub/sub bus with wildcards, backpressure and acknowledgements
+	interface_example.EventBusTest()
+
+	// Durable outbox with retries, dead letters and replay
+	interface_example.OutboxTest()
+
 	// Interface Composition
 	file := &interface_example.File{Name: "example.txt"}
 	data := []byte("Hello, Interface Composition!")
 
 	interface_example.Process(data, file)
 
+	// Interface Composition - real ReadWriteSeekCloser implementations
+	interface_example.ReadWriterTest()
+
+	// Interface Composition - stackable stream layers
+	interface_example.StreamTest()
+
+	// Interface Composition - virtual filesystem with OS, memory and overlay backends
+	interface_example.VFSTest()
+
 	// Interface Polymorphism
 	mysqlDB := &interface_example.MySQL{Connection: "user:pass@tcp(localhost:3306)/dbname"}
 	result, err := interface_example.ExecuteQuery(mysqlDB, "SELECT * FROM users")
@@ -301,9 +686,12 @@ func main() {
 	concurrency.WorkerPoolTest()
 	concurrency.WebCrawlerTest()
 	concurrency.LoggerTest()
+	concurrency.SafeGroupTest()
 
 	// Std - JSON
 	std.JSONTest()
+	std.JSONPathTest()
+	std.JSONSchemaTest()
 	std.FileRWTest()
 	std.TimeTest()
 	std.HashTest()
@@ -0,0 +1,717 @@
+package std
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Decoding JSON into `any` gives a tree of map[string]any, []any, string, float64, bool and nil.
+// Walking it by hand means a type assertion per step (see JSONTest). A path expression
+// does the walking instead:
+//
+// $                 the root
+// .name ['name']    an object member
+// [0] [-1]          an array element, negative counts from the end
+// .* [*]            every member of an object or element of an array
+// [?(@.age >= 18)]  the members/elements matching a filter; @ is the current one
+//
+// Filters compare with == != < <= > >= against numbers, 'strings', true, false and null,
+// can be combined with && and ||, and a bare @.name tests that the member exists.
+
+var (
+	ErrPathSyntax   = errors.New("invalid path")
+	ErrPathNotFound = errors.New("not found")
+	ErrPathType     = errors.New("type mismatch")
+)
+
+// PathError says which segment of the path failed, e.g. `$.data.tags[5]: at [5]: ...`.
+type PathError struct {
+	Path    string
+	Segment string
+	Err     error
+}
+
+func (e *PathError) Error() string {
+	return fmt.Sprintf("%s: at %s: %v", e.Path, e.Segment, e.Err)
+}
+
+func (e *PathError) Unwrap() error {
+	return e.Err
+}
+
+type segmentKind int
+
+const (
+	segKey segmentKind = iota
+	segIndex
+	segWildcard
+	segFilter
+)
+
+type segment struct {
+	text   string // as written in the expression, for error messages
+	kind   segmentKind
+	key    string
+	index  int
+	filter filterExpr
+}
+
+// Path is a compiled expression; compile once and reuse it on many documents.
+type Path struct {
+	expr     string
+	segments []segment
+}
+
+func CompilePath(expr string) (*Path, error) {
+	p := &pathParser{expr: expr}
+	if !p.consume("$") {
+		return nil, p.errorf("must start with $")
+	}
+	segs, err := p.segments(false)
+	if err != nil {
+		return nil, err
+	}
+	return &Path{expr: expr, segments: segs}, nil
+}
+
+func (p *Path) String() string {
+	return p.expr
+}
+
+// Select returns every value the path matches, in document order (object members by key).
+// When nothing matches, the *PathError names the segment where the trail ran out.
+func (p *Path) Select(doc any) ([]any, error) {
+	nodes := []any{doc}
+	for _, seg := range p.segments {
+		var next []any
+		var reason error
+		for _, n := range nodes {
+			var err error
+			if next, err = seg.apply(n, next); err != nil {
+				reason = err
+			}
+		}
+
+		if len(next) == 0 {
+			switch {
+			case len(nodes) > 1:
+				reason = fmt.Errorf("%w: no match in any of %d values", ErrPathNotFound, len(nodes))
+			case reason == nil:
+				reason = fmt.Errorf("%w: no match", ErrPathNotFound)
+			}
+			return nil, &PathError{Path: p.expr, Segment: seg.text, Err: reason}
+		}
+		nodes = next
+	}
+	return nodes, nil
+}
+
+// Get returns the single value the path matches.
+func (p *Path) Get(doc any) (any, error) {
+	values, err := p.Select(doc)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) != 1 {
+		return nil, &PathError{Path: p.expr, Segment: p.last(), Err: fmt.Errorf("matched %d values, expected exactly one", len(values))}
+	}
+	return values[0], nil
+}
+
+func (p *Path) last() string {
+	if len(p.segments) == 0 {
+		return "$"
+	}
+	return p.segments[len(p.segments)-1].text
+}
+
+// Query compiles expr and returns all matches.
+func Query(doc any, expr string) ([]any, error) {
+	p, err := CompilePath(expr)
+	if err != nil {
+		return nil, err
+	}
+	return p.Select(doc)
+}
+
+func Get(doc any, expr string) (any, error) {
+	p, err := CompilePath(expr)
+	if err != nil {
+		return nil, err
+	}
+	return p.Get(doc)
+}
+
+func GetString(doc any, expr string) (string, error) {
+	return getAs(doc, expr, "string", func(v any) (string, bool) {
+		s, ok := v.(string)
+		return s, ok
+	})
+}
+
+// GetInt accepts whole numbers only; 2.5 is a type mismatch rather than silently 2.
+// So is a number outside int's range. MaxInt isn't a float64, it rounds up to 2^63, so the
+// upper bound is checked against MaxInt+1, which is exact.
+func GetInt(doc any, expr string) (int, error) {
+	return getAs(doc, expr, "integer", func(v any) (int, bool) {
+		f, ok := v.(float64)
+		if !ok || f != math.Trunc(f) || f < math.MinInt || f >= math.MaxInt+1.0 {
+			return 0, false
+		}
+		return int(f), true
+	})
+}
+
+func GetSlice(doc any, expr string) ([]any, error) {
+	return getAs(doc, expr, "array", func(v any) ([]any, bool) {
+		s, ok := v.([]any)
+		return s, ok
+	})
+}
+
+func getAs[T any](doc any, expr, want string, convert func(any) (T, bool)) (T, error) {
+	var zero T
+	p, err := CompilePath(expr)
+	if err != nil {
+		return zero, err
+	}
+	v, err := p.Get(doc)
+	if err != nil {
+		return zero, err
+	}
+	out, ok := convert(v)
+	if !ok {
+		return zero, &PathError{Path: expr, Segment: p.last(), Err: fmt.Errorf("%w: expected %s, got %s", ErrPathType, want, describe(v))}
+	}
+	return out, nil
+}
+
+// apply appends the values seg selects from node to out. The error explains an empty
+// result for this node, e.g. a missing key or the wrong container type.
+func (seg segment) apply(node any, out []any) ([]any, error) {
+	switch seg.kind {
+	case segKey:
+		obj, ok := node.(map[string]any)
+		if !ok {
+			return out, fmt.Errorf("%w: expected object, got %s", ErrPathType, describe(node))
+		}
+		v, ok := obj[seg.key]
+		if !ok {
+			return out, fmt.Errorf("%w: no key %q", ErrPathNotFound, seg.key)
+		}
+		return append(out, v), nil
+
+	case segIndex:
+		arr, ok := node.([]any)
+		if !ok {
+			return out, fmt.Errorf("%w: expected array, got %s", ErrPathType, describe(node))
+		}
+		i := seg.index
+		if i < 0 {
+			i += len(arr)
+		}
+		if i < 0 || i >= len(arr) {
+			return out, fmt.Errorf("%w: index %d out of range (length %d)", ErrPathNotFound, seg.index, len(arr))
+		}
+		return append(out, arr[i]), nil
+	}
+
+	// wildcard and filter both go through the children
+	children, ok := childrenOf(node)
+	if !ok {
+		return out, fmt.Errorf("%w: expected object or array, got %s", ErrPathType, describe(node))
+	}
+	for _, c := range children {
+		if seg.kind == segWildcard || seg.filter.match(c) {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func childrenOf(node any) ([]any, bool) {
+	switch v := node.(type) {
+	case []any:
+		return v, true
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		children := make([]any, len(keys))
+		for i, k := range keys {
+			children[i] = v[k]
+		}
+		return children, true
+	}
+	return nil, false
+}
+
+// describe names a value's JSON type for error messages.
+func describe(v any) string {
+	switch v := v.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return fmt.Sprintf("string %q", v)
+	case float64:
+		return fmt.Sprintf("number %v", v)
+	case bool:
+		return fmt.Sprintf("boolean %v", v)
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// Filters
+
+type filterExpr interface {
+	match(node any) bool
+}
+
+type orExpr []filterExpr
+
+func (e orExpr) match(node any) bool {
+	for _, sub := range e {
+		if sub.match(node) {
+			return true
+		}
+	}
+	return false
+}
+
+type andExpr []filterExpr
+
+func (e andExpr) match(node any) bool {
+	for _, sub := range e {
+		if !sub.match(node) {
+			return false
+		}
+	}
+	return true
+}
+
+// operand is either a path relative to @ or a literal.
+type operand struct {
+	relative []segment
+	isPath   bool
+	literal  any
+}
+
+func (o operand) resolve(node any) (any, bool) {
+	if !o.isPath {
+		return o.literal, true
+	}
+	for _, seg := range o.relative {
+		next, _ := seg.apply(node, nil)
+		if len(next) == 0 {
+			return nil, false
+		}
+		node = next[0]
+	}
+	return node, true
+}
+
+type comparison struct {
+	left, right operand
+	op          string // empty for an existence test
+}
+
+func (c comparison) match(node any) bool {
+	l, ok := c.left.resolve(node)
+	if c.op == "" || !ok {
+		return ok
+	}
+	r, ok := c.right.resolve(node)
+	if !ok {
+		return false
+	}
+
+	switch c.op {
+	case "==":
+		return equalJSON(l, r)
+	case "!=":
+		return !equalJSON(l, r)
+	}
+
+	// ordering only makes sense between two numbers or two strings
+	var cmp int
+	switch lv := l.(type) {
+	case float64:
+		rv, ok := r.(float64)
+		if !ok {
+			return false
+		}
+		cmp = compareFloat(lv, rv)
+	case string:
+		rv, ok := r.(string)
+		if !ok {
+			return false
+		}
+		cmp = strings.Compare(lv, rv)
+	default:
+		return false
+	}
+
+	switch c.op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	default: // ">="
+		return cmp >= 0
+	}
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// equalJSON compares scalars; objects and arrays are never equal to anything.
+func equalJSON(a, b any) bool {
+	switch a.(type) {
+	case map[string]any, []any:
+		return false
+	}
+	switch b.(type) {
+	case map[string]any, []any:
+		return false
+	}
+	return a == b
+}
+
+// Parser
+
+type pathParser struct {
+	expr string
+	pos  int
+}
+
+func (p *pathParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w %q: %s (offset %d)", ErrPathSyntax, p.expr, fmt.Sprintf(format, args...), p.pos)
+}
+
+func (p *pathParser) consume(s string) bool {
+	if strings.HasPrefix(p.expr[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+func (p *pathParser) peek() byte {
+	if p.pos < len(p.expr) {
+		return p.expr[p.pos]
+	}
+	return 0
+}
+
+func (p *pathParser) skipSpace() {
+	for p.peek() == ' ' {
+		p.pos++
+	}
+}
+
+// segments parses segments until the end of the expression, or, inside a filter,
+// until something that isn't a segment (an operator or the closing bracket).
+func (p *pathParser) segments(inFilter bool) ([]segment, error) {
+	var segs []segment
+	for p.pos < len(p.expr) {
+		start := p.pos
+		var seg segment
+
+		switch p.peek() {
+		case '.':
+			p.pos++
+			if p.consume("*") {
+				seg.kind = segWildcard
+				break
+			}
+			name := p.name()
+			if name == "" {
+				return nil, p.errorf("expected a name after '.'")
+			}
+			seg.kind, seg.key = segKey, name
+
+		case '[':
+			p.pos++
+			p.skipSpace()
+			switch c := p.peek(); {
+			case c == '*':
+				p.pos++
+				seg.kind = segWildcard
+			case c == '\'' || c == '"':
+				key, err := p.quoted()
+				if err != nil {
+					return nil, err
+				}
+				seg.kind, seg.key = segKey, key
+			case c == '?':
+				if inFilter {
+					return nil, p.errorf("nested filters are not supported")
+				}
+				p.pos++
+				f, err := p.filter()
+				if err != nil {
+					return nil, err
+				}
+				seg.kind, seg.filter = segFilter, f
+			default:
+				n, ok := p.integer()
+				if !ok {
+					return nil, p.errorf("expected an index, a quoted name, * or ? after '['")
+				}
+				seg.kind, seg.index = segIndex, n
+			}
+			p.skipSpace()
+			if !p.consume("]") {
+				return nil, p.errorf("expected ']'")
+			}
+
+		default:
+			if inFilter {
+				return segs, nil
+			}
+			return nil, p.errorf("unexpected %q", p.peek())
+		}
+
+		seg.text = p.expr[start:p.pos]
+		segs = append(segs, seg)
+	}
+	return segs, nil
+}
+
+func (p *pathParser) name() string {
+	start := p.pos
+	for p.pos < len(p.expr) {
+		c := p.expr[p.pos]
+		if !(c == '_' || c == '-' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			break
+		}
+		p.pos++
+	}
+	return p.expr[start:p.pos]
+}
+
+func (p *pathParser) integer() (int, bool) {
+	start := p.pos
+	if p.peek() == '-' {
+		p.pos++
+	}
+	for p.peek() >= '0' && p.peek() <= '9' {
+		p.pos++
+	}
+	n, err := strconv.Atoi(p.expr[start:p.pos])
+	if err != nil {
+		p.pos = start
+		return 0, false
+	}
+	return n, true
+}
+
+// quoted reads a 'single' or "double" quoted string; a backslash escapes the next character.
+func (p *pathParser) quoted() (string, error) {
+	quote := p.peek()
+	p.pos++
+	var b strings.Builder
+	for p.pos < len(p.expr) {
+		c := p.expr[p.pos]
+		p.pos++
+		switch c {
+		case quote:
+			return b.String(), nil
+		case '\\':
+			if p.pos == len(p.expr) {
+				return "", p.errorf("unterminated string")
+			}
+			c = p.expr[p.pos]
+			p.pos++
+		}
+		b.WriteByte(c)
+	}
+	return "", p.errorf("unterminated string")
+}
+
+func (p *pathParser) filter() (filterExpr, error) {
+	p.skipSpace()
+	paren := p.consume("(")
+	f, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if paren && !p.consume(")") {
+		return nil, p.errorf("expected ')'")
+	}
+	return f, nil
+}
+
+func (p *pathParser) or() (filterExpr, error) {
+	var terms orExpr
+	for {
+		t, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, t)
+		p.skipSpace()
+		if !p.consume("||") {
+			break
+		}
+	}
+	if len(terms) == 1 {
+		return terms[0], nil
+	}
+	return terms, nil
+}
+
+func (p *pathParser) and() (filterExpr, error) {
+	var terms andExpr
+	for {
+		t, err := p.comparison()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, t)
+		p.skipSpace()
+		if !p.consume("&&") {
+			break
+		}
+	}
+	if len(terms) == 1 {
+		return terms[0], nil
+	}
+	return terms, nil
+}
+
+func (p *pathParser) comparison() (filterExpr, error) {
+	left, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.consume(op) {
+			right, err := p.operand()
+			if err != nil {
+				return nil, err
+			}
+			return comparison{left: left, right: right, op: op}, nil
+		}
+	}
+	if !left.isPath {
+		return nil, p.errorf("a literal on its own is not a condition")
+	}
+	return comparison{left: left}, nil
+}
+
+func (p *pathParser) operand() (operand, error) {
+	p.skipSpace()
+	switch c := p.peek(); {
+	case c == '@':
+		p.pos++
+		segs, err := p.segments(true)
+		if err != nil {
+			return operand{}, err
+		}
+		return operand{relative: segs, isPath: true}, nil
+	case c == '\'' || c == '"':
+		s, err := p.quoted()
+		return operand{literal: s}, err
+	case p.consume("true"):
+		return operand{literal: true}, nil
+	case p.consume("false"):
+		return operand{literal: false}, nil
+	case p.consume("null"):
+		return operand{literal: nil}, nil
+	}
+
+	start := p.pos
+	for strings.IndexByte("+-.0123456789eE", p.peek()) >= 0 && p.peek() != 0 {
+		p.pos++
+	}
+	f, err := strconv.ParseFloat(p.expr[start:p.pos], 64)
+	if err != nil {
+		p.pos = start
+		return operand{}, p.errorf("expected @, a number, a string, true, false or null")
+	}
+	return operand{literal: f}, nil
+}
+
+func JSONPathTest() {
+	body := `{
+		"code": 0,
+		"data": {
+			"name": "Bob",
+			"age": 25,
+			"tags": ["golang", "stdlib"],
+			"friends": [
+				{"name": "Alice", "age": 30, "email": "alice@example.com"},
+				{"name": "Carol", "age": 17},
+				{"name": "Dave", "age": 41, "email": "dave@example.com"}
+			]
+		}
+	}`
+	var doc any
+	if err := json.Unmarshal([]byte(body), &doc); err != nil {
+		panic(err)
+	}
+
+	// The typed getters replace the step-by-step assertions in JSONTest
+	name, _ := GetString(doc, "$.data.name")
+	age, _ := GetInt(doc, "$.data.age")
+	tag, _ := GetString(doc, "$.data.tags[0]")
+	tags, _ := GetSlice(doc, "$.data.tags")
+	fmt.Printf("name=%s age=%d first tag=%s tags=%v\n", name, age, tag, tags)
+
+	for _, expr := range []string{
+		"$.data.tags[-1]",
+		"$.data.friends[*].name",
+		"$.data.friends[?(@.age >= 18)].name",
+		"$.data.friends[?(@.email)].email",
+		"$.data.friends[?(@.age < 40 && @.name != 'Carol')].name",
+		"$['data']['friends'][1]['age']",
+	} {
+		values, err := Query(doc, expr)
+		fmt.Printf("%-56s %v %v\n", expr, values, errOrEmpty(err))
+	}
+
+	// Errors name the segment that failed
+	_, err := GetString(doc, "$.data.tags[5]")
+	fmt.Println(err)
+	_, err = GetInt(doc, "$.data.name")
+	fmt.Println(err)
+	_, err = GetString(doc, "$.data.profile.city")
+	fmt.Println(err)
+	_, err = GetString(doc, "$.data.friends[*].name")
+	fmt.Println(err)
+	_, err = GetString(doc, "$.data.friends[?(@.age > 100)].name")
+	fmt.Println(err, "| not found:", errors.Is(err, ErrPathNotFound))
+	_, err = Query(doc, "$.data[")
+	fmt.Println(err)
+}
+
+func errOrEmpty(err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return ""
+}
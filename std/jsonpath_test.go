@@ -0,0 +1,183 @@
+package std
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+const pathDoc = `{
+	"code": 0,
+	"data": {
+		"name": "Bob",
+		"age": 25,
+		"ratio": 2.5,
+		"big": 9223372036854775808,
+		"small": -9223372036854775808,
+		"tags": ["golang", "stdlib"],
+		"odd key": {"a.b": true},
+		"friends": [
+			{"name": "Alice", "age": 30, "email": "alice@example.com"},
+			{"name": "Carol", "age": 17},
+			{"name": "Dave", "age": 41, "email": "dave@example.com"}
+		]
+	}
+}`
+
+func decodeDoc(t *testing.T) any {
+	t.Helper()
+	var doc any
+	if err := json.Unmarshal([]byte(pathDoc), &doc); err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestQuery(t *testing.T) {
+	doc := decodeDoc(t)
+	tests := []struct {
+		expr string
+		want []any
+	}{
+		{"$.code", []any{0.0}},
+		{"$.data.tags[0]", []any{"golang"}},
+		{"$.data.tags[-1]", []any{"stdlib"}},
+		{"$['data']['odd key']['a.b']", []any{true}},
+		{`$["data"].name`, []any{"Bob"}},
+		{"$.data.tags[*]", []any{"golang", "stdlib"}},
+		{"$.data.tags.*", []any{"golang", "stdlib"}},
+		{"$.data.friends[*].name", []any{"Alice", "Carol", "Dave"}},
+		{"$.data.friends[1].*", []any{17.0, "Carol"}}, // object members by key
+		{"$.data.friends[?(@.age >= 18)].name", []any{"Alice", "Dave"}},
+		{"$.data.friends[?(@.email)].name", []any{"Alice", "Dave"}},
+		{"$.data.friends[?(@.age < 40 && @.name != 'Carol')].name", []any{"Alice"}},
+		{"$.data.friends[?(@.age > 40 || @.name == 'Carol')].name", []any{"Carol", "Dave"}},
+		{"$.data.friends[?(@.name > 'B')].age", []any{17.0, 41.0}},
+		{"$.data.friends[?(@.email == null)].name", nil}, // a missing member isn't null
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := Query(doc, tt.expr)
+			if tt.want == nil {
+				if !errors.Is(err, ErrPathNotFound) {
+					t.Errorf("Query = %v, %v; want ErrPathNotFound", got, err)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Query = %v, %v; want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	doc := decodeDoc(t)
+	tests := []struct {
+		expr    string
+		want    error
+		segment string
+	}{
+		{"$.data.missing", ErrPathNotFound, ".missing"},
+		{"$.data.profile.city", ErrPathNotFound, ".profile"},
+		{"$.data.tags[5]", ErrPathNotFound, "[5]"},
+		{"$.data.tags[-3]", ErrPathNotFound, "[-3]"},
+		{"$.data.name.first", ErrPathType, ".first"},
+		{"$.data.name[0]", ErrPathType, "[0]"},
+		{"$.data.age[*]", ErrPathType, "[*]"},
+		{"$.data.friends[*].email.x", ErrPathNotFound, ".x"}, // several values, none of them objects
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := Query(doc, tt.expr)
+			var pathErr *PathError
+			if !errors.Is(err, tt.want) || !errors.As(err, &pathErr) || pathErr.Segment != tt.segment {
+				t.Errorf("Query = %v, want %v at %s", err, tt.want, tt.segment)
+			}
+		})
+	}
+}
+
+func TestCompilePathSyntax(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"data.name",
+		"$.",
+		"$.data[",
+		"$.data[x]",
+		"$['unterminated",
+		"$.data[?(@.a[?(@.b)])]",
+		"$.data[?(@.a == )]",
+		"$.data[?(1)]",
+		"$.data[?(@.a]",
+	} {
+		if _, err := CompilePath(expr); !errors.Is(err, ErrPathSyntax) {
+			t.Errorf("CompilePath(%q) = %v, want ErrPathSyntax", expr, err)
+		}
+	}
+}
+
+func TestTypedGetters(t *testing.T) {
+	doc := decodeDoc(t)
+
+	t.Run("GetString", func(t *testing.T) {
+		if s, err := GetString(doc, "$.data.name"); err != nil || s != "Bob" {
+			t.Errorf("GetString = %q, %v", s, err)
+		}
+		if _, err := GetString(doc, "$.data.age"); !errors.Is(err, ErrPathType) {
+			t.Errorf("GetString of a number = %v, want ErrPathType", err)
+		}
+		if _, err := GetString(doc, "$.data.nickname"); !errors.Is(err, ErrPathNotFound) {
+			t.Errorf("GetString of a missing key = %v, want ErrPathNotFound", err)
+		}
+		if _, err := GetString(doc, "$.data.friends[*].name"); err == nil {
+			t.Error("GetString of several values succeeded")
+		}
+	})
+
+	t.Run("GetInt", func(t *testing.T) {
+		if n, err := GetInt(doc, "$.data.age"); err != nil || n != 25 {
+			t.Errorf("GetInt = %d, %v", n, err)
+		}
+		for _, expr := range []string{"$.data.ratio", "$.data.name", "$.data.tags"} {
+			if _, err := GetInt(doc, expr); !errors.Is(err, ErrPathType) {
+				t.Errorf("GetInt(%s) = %v, want ErrPathType", expr, err)
+			}
+		}
+		if _, err := GetInt(doc, "$.data.nickname"); !errors.Is(err, ErrPathNotFound) {
+			t.Errorf("GetInt of a missing key = %v, want ErrPathNotFound", err)
+		}
+	})
+
+	t.Run("GetInt range", func(t *testing.T) {
+		// 2^63 is one past MaxInt64; converting it to int would overflow
+		if math.MaxInt == math.MaxInt64 {
+			if n, err := GetInt(doc, "$.data.big"); !errors.Is(err, ErrPathType) {
+				t.Errorf("GetInt(2^63) = %d, %v; want ErrPathType", n, err)
+			}
+			if n, err := GetInt(doc, "$.data.small"); err != nil || n != math.MinInt64 {
+				t.Errorf("GetInt(-2^63) = %d, %v; want MinInt64", n, err)
+			}
+		}
+		huge := map[string]any{"n": math.Inf(1), "nan": math.NaN()}
+		for _, expr := range []string{"$.n", "$.nan"} {
+			if _, err := GetInt(huge, expr); !errors.Is(err, ErrPathType) {
+				t.Errorf("GetInt(%s) = %v, want ErrPathType", expr, err)
+			}
+		}
+	})
+
+	t.Run("GetSlice", func(t *testing.T) {
+		if s, err := GetSlice(doc, "$.data.tags"); err != nil || len(s) != 2 {
+			t.Errorf("GetSlice = %v, %v", s, err)
+		}
+		if _, err := GetSlice(doc, "$.data"); !errors.Is(err, ErrPathType) {
+			t.Errorf("GetSlice of an object = %v, want ErrPathType", err)
+		}
+		if _, err := GetSlice(doc, "$.data.friends[9]"); !errors.Is(err, ErrPathNotFound) {
+			t.Errorf("GetSlice out of range = %v, want ErrPathNotFound", err)
+		}
+	})
+}
@@ -0,0 +1,436 @@
+package std
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// A validator for a subset of JSON Schema (draft 2020-12). Checking input against a schema
+// before decoding it into a struct catches what json.Unmarshal lets through: missing fields,
+// negative ages, unknown roles. Supported keywords:
+//
+// type (string or list), enum, const
+// minimum, maximum, exclusiveMinimum, exclusiveMaximum    numbers
+// minLength, maxLength, pattern                          strings
+// properties, required, additionalProperties             objects
+// items, minItems, maxItems, uniqueItems                 arrays
+//
+// Other keywords are ignored, as the spec does for unknown ones. true and false are
+// schemas too: true accepts anything, false nothing.
+//
+// Every violation is reported, each with a JSON pointer (RFC 6901) to the offending value,
+// e.g. /friends/1/age.
+
+var ErrInvalidSchema = errors.New("invalid schema")
+
+var schemaTypes = []string{"null", "boolean", "object", "array", "number", "integer", "string"}
+
+type Schema struct {
+	always *bool // set for the boolean schemas true and false
+
+	types            []string
+	enum             []any
+	constValue       *any
+	minimum          *float64
+	maximum          *float64
+	exclusiveMinimum *float64
+	exclusiveMaximum *float64
+	minLength        *int
+	maxLength        *int
+	pattern          *regexp.Regexp
+	properties       map[string]*Schema
+	required         []string
+	additional       *Schema
+	items            *Schema
+	minItems         *int
+	maxItems         *int
+	uniqueItems      bool
+}
+
+// schemaJSON is the wire form; nested schemas stay raw until compiled.
+type schemaJSON struct {
+	Type                 json.RawMessage            `json:"type"`
+	Enum                 []any                      `json:"enum"`
+	Const                json.RawMessage            `json:"const"`
+	Minimum              *float64                   `json:"minimum"`
+	Maximum              *float64                   `json:"maximum"`
+	ExclusiveMinimum     *float64                   `json:"exclusiveMinimum"`
+	ExclusiveMaximum     *float64                   `json:"exclusiveMaximum"`
+	MinLength            *int                       `json:"minLength"`
+	MaxLength            *int                       `json:"maxLength"`
+	Pattern              *string                    `json:"pattern"`
+	Properties           map[string]json.RawMessage `json:"properties"`
+	Required             []string                   `json:"required"`
+	AdditionalProperties json.RawMessage            `json:"additionalProperties"`
+	Items                json.RawMessage            `json:"items"`
+	MinItems             *int                       `json:"minItems"`
+	MaxItems             *int                       `json:"maxItems"`
+	UniqueItems          bool                       `json:"uniqueItems"`
+}
+
+func CompileSchema(data []byte) (*Schema, error) {
+	return compileSchema(data, "")
+}
+
+// MustCompileSchema is for schemas known at compile time, e.g. package-level variables.
+func MustCompileSchema(data string) *Schema {
+	s, err := CompileSchema([]byte(data))
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// compileSchema compiles one (sub)schema; at is its pointer within the schema document.
+func compileSchema(data []byte, at string) (*Schema, error) {
+	fail := func(format string, args ...any) (*Schema, error) {
+		return nil, fmt.Errorf("%w at %s: %s", ErrInvalidSchema, displayPointer(at), fmt.Sprintf(format, args...))
+	}
+
+	// only true, false and objects are schemas; null would unmarshal into either silently
+	switch trimmed := bytes.TrimSpace(data); {
+	case string(trimmed) == "true" || string(trimmed) == "false":
+		b := string(trimmed) == "true"
+		return &Schema{always: &b}, nil
+	case len(trimmed) == 0 || trimmed[0] != '{':
+		return fail("a schema must be an object, true or false, got %s", trimmed)
+	}
+
+	var raw schemaJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fail("%v", err)
+	}
+
+	s := &Schema{
+		enum:             raw.Enum,
+		minimum:          raw.Minimum,
+		maximum:          raw.Maximum,
+		exclusiveMinimum: raw.ExclusiveMinimum,
+		exclusiveMaximum: raw.ExclusiveMaximum,
+		minLength:        raw.MinLength,
+		maxLength:        raw.MaxLength,
+		required:         raw.Required,
+		minItems:         raw.MinItems,
+		maxItems:         raw.MaxItems,
+		uniqueItems:      raw.UniqueItems,
+	}
+
+	if raw.Type != nil {
+		var one string
+		if err := json.Unmarshal(raw.Type, &one); err == nil {
+			s.types = []string{one}
+		} else if err := json.Unmarshal(raw.Type, &s.types); err != nil {
+			return fail("type must be a string or a list of strings")
+		}
+		for _, t := range s.types {
+			if !slices.Contains(schemaTypes, t) {
+				return fail("unknown type %q", t)
+			}
+		}
+	}
+
+	if raw.Const != nil {
+		var v any
+		if err := json.Unmarshal(raw.Const, &v); err != nil {
+			return fail("const: %v", err)
+		}
+		s.constValue = &v
+	}
+
+	if raw.Pattern != nil {
+		re, err := regexp.Compile(*raw.Pattern)
+		if err != nil {
+			return fail("pattern: %v", err)
+		}
+		s.pattern = re
+	}
+
+	if len(raw.Properties) > 0 {
+		s.properties = make(map[string]*Schema, len(raw.Properties))
+		for name, sub := range raw.Properties {
+			compiled, err := compileSchema(sub, at+"/properties/"+escapePointer(name))
+			if err != nil {
+				return nil, err
+			}
+			s.properties[name] = compiled
+		}
+	}
+
+	var err error
+	if raw.AdditionalProperties != nil {
+		if s.additional, err = compileSchema(raw.AdditionalProperties, at+"/additionalProperties"); err != nil {
+			return nil, err
+		}
+	}
+	if raw.Items != nil {
+		if s.items, err = compileSchema(raw.Items, at+"/items"); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Violation is one failed check. Pointer locates the value in the instance ("" is the root).
+type Violation struct {
+	Pointer string
+	Keyword string
+	Message string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s: %s", displayPointer(v.Pointer), v.Keyword, v.Message)
+}
+
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		lines[i] = v.String()
+	}
+	return fmt.Sprintf("%d schema violation(s): %s", len(e.Violations), strings.Join(lines, "; "))
+}
+
+// Validate checks a decoded value (as produced by json.Unmarshal into any) and returns
+// a *ValidationError listing every violation, or nil.
+func (s *Schema) Validate(v any) error {
+	var out []Violation
+	s.validate(v, "", &out)
+	if len(out) > 0 {
+		return &ValidationError{Violations: out}
+	}
+	return nil
+}
+
+func (s *Schema) ValidateBytes(data []byte) error {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	return s.Validate(v)
+}
+
+// Decode validates data and only then unmarshals it into target.
+func (s *Schema) Decode(data []byte, target any) error {
+	if err := s.ValidateBytes(data); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
+
+func (s *Schema) validate(v any, at string, out *[]Violation) {
+	report := func(keyword, format string, args ...any) {
+		*out = append(*out, Violation{Pointer: at, Keyword: keyword, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if s.always != nil {
+		if !*s.always {
+			report("false", "no value is allowed here")
+		}
+		return
+	}
+
+	if len(s.types) > 0 && !slices.ContainsFunc(s.types, func(t string) bool { return hasType(v, t) }) {
+		report("type", "must be %s, got %s", strings.Join(s.types, " or "), jsonTypeOf(v))
+		return // the other checks would only repeat the same problem
+	}
+	if s.enum != nil && !slices.ContainsFunc(s.enum, func(e any) bool { return reflect.DeepEqual(e, v) }) {
+		report("enum", "must be one of %s, got %s", compactJSON(s.enum), compactJSON(v))
+	}
+	if s.constValue != nil && !reflect.DeepEqual(*s.constValue, v) {
+		report("const", "must be %s, got %s", compactJSON(*s.constValue), compactJSON(v))
+	}
+
+	switch v := v.(type) {
+	case float64:
+		if s.minimum != nil && v < *s.minimum {
+			report("minimum", "must be >= %v, got %v", *s.minimum, v)
+		}
+		if s.maximum != nil && v > *s.maximum {
+			report("maximum", "must be <= %v, got %v", *s.maximum, v)
+		}
+		if s.exclusiveMinimum != nil && v <= *s.exclusiveMinimum {
+			report("exclusiveMinimum", "must be > %v, got %v", *s.exclusiveMinimum, v)
+		}
+		if s.exclusiveMaximum != nil && v >= *s.exclusiveMaximum {
+			report("exclusiveMaximum", "must be < %v, got %v", *s.exclusiveMaximum, v)
+		}
+
+	case string:
+		n := utf8.RuneCountInString(v)
+		if s.minLength != nil && n < *s.minLength {
+			report("minLength", "must be at least %d characters, got %d", *s.minLength, n)
+		}
+		if s.maxLength != nil && n > *s.maxLength {
+			report("maxLength", "must be at most %d characters, got %d", *s.maxLength, n)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			report("pattern", "must match %s, got %q", s.pattern, v)
+		}
+
+	case map[string]any:
+		for _, name := range s.required {
+			if _, ok := v[name]; !ok {
+				report("required", "missing property %q", name)
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			child := at + "/" + escapePointer(k)
+			if sub, ok := s.properties[k]; ok {
+				sub.validate(v[k], child, out)
+			} else if s.additional != nil {
+				if s.additional.always != nil && !*s.additional.always {
+					*out = append(*out, Violation{Pointer: child, Keyword: "additionalProperties", Message: "property is not allowed"})
+				} else {
+					s.additional.validate(v[k], child, out)
+				}
+			}
+		}
+
+	case []any:
+		if s.minItems != nil && len(v) < *s.minItems {
+			report("minItems", "must have at least %d items, got %d", *s.minItems, len(v))
+		}
+		if s.maxItems != nil && len(v) > *s.maxItems {
+			report("maxItems", "must have at most %d items, got %d", *s.maxItems, len(v))
+		}
+		if s.uniqueItems {
+			for i := range v {
+				for j := i + 1; j < len(v); j++ {
+					if reflect.DeepEqual(v[i], v[j]) {
+						report("uniqueItems", "items %d and %d are equal", i, j)
+					}
+				}
+			}
+		}
+		if s.items != nil {
+			for i, item := range v {
+				s.items.validate(item, fmt.Sprintf("%s/%d", at, i), out)
+			}
+		}
+	}
+}
+
+func hasType(v any, t string) bool {
+	switch t {
+	case "integer":
+		f, ok := v.(float64)
+		return ok && f == math.Trunc(f) && !math.IsInf(f, 0)
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	}
+	return jsonTypeOf(v) == t
+}
+
+func jsonTypeOf(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func compactJSON(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// escapePointer escapes a key for use in a JSON pointer: ~ becomes ~0 and / becomes ~1.
+func escapePointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
+
+func displayPointer(p string) string {
+	if p == "" {
+		return "(root)"
+	}
+	return p
+}
+
+var personSchema = MustCompileSchema(`{
+	"type": "object",
+	"required": ["name", "age"],
+	"properties": {
+		"name": {"type": "string", "minLength": 1, "maxLength": 50},
+		"age": {"type": "integer", "minimum": 0, "maximum": 150},
+		"email": {"type": "string", "pattern": "^[^@\\s]+@[^@\\s]+\\.[a-z]+$"},
+		"role": {"enum": ["admin", "member", "guest"]},
+		"tags": {"type": "array", "items": {"type": "string"}, "maxItems": 3, "uniqueItems": true},
+		"friends": {
+			"type": "array",
+			"items": {
+				"type": "object",
+				"required": ["name"],
+				"properties": {
+					"name": {"type": "string"},
+					"age": {"type": "integer", "minimum": 0}
+				}
+			}
+		}
+	},
+	"additionalProperties": false
+}`)
+
+func JSONSchemaTest() {
+	// Valid input goes on to be decoded into the struct
+	var p Person
+	err := personSchema.Decode([]byte(`{"name":"Alice","age":30,"role":"admin","tags":["go"]}`), &p)
+	fmt.Printf("valid: %+v err=%v\n", p, err)
+
+	// Invalid input reports every problem, not just the first
+	bad := `{
+		"name": "",
+		"age": 30.5,
+		"email": "not-an-email",
+		"role": "owner",
+		"tags": ["go", "go", 7, "x"],
+		"friends": [{"name": "Bob", "age": 4}, {"age": -1}],
+		"nickname": "Al"
+	}`
+	err = personSchema.Decode([]byte(bad), &p)
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		fmt.Printf("%d violations:\n", len(verr.Violations))
+		for _, v := range verr.Violations {
+			fmt.Println("  " + v.String())
+		}
+	}
+
+	// The same schema works on already-decoded values
+	err = personSchema.Validate([]any{"not", "an", "object"})
+	fmt.Println(err)
+
+	_, err = CompileSchema([]byte(`{"type":"text"}`))
+	fmt.Println(err)
+	_, err = CompileSchema([]byte(`{"properties":{"code":{"pattern":"[a-"}}}`))
+	fmt.Println(err)
+}
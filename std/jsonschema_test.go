@@ -0,0 +1,145 @@
+package std
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// violations validates instance against schema and returns "pointer keyword" for each violation.
+func violations(t *testing.T, schema, instance string) []string {
+	t.Helper()
+	s, err := CompileSchema([]byte(schema))
+	if err != nil {
+		t.Fatalf("CompileSchema(%s): %v", schema, err)
+	}
+	err = s.ValidateBytes([]byte(instance))
+	if err == nil {
+		return nil
+	}
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("ValidateBytes(%s) = %v, want a *ValidationError", instance, err)
+	}
+	out := make([]string, len(verr.Violations))
+	for i, v := range verr.Violations {
+		out[i] = v.Pointer + " " + v.Keyword
+	}
+	return out
+}
+
+func TestSchemaKeywords(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   string
+		instance string
+		want     []string
+	}{
+		{"true accepts anything", `true`, `{"a":[1,null]}`, nil},
+		{"false accepts nothing", `false`, `1`, []string{" false"}},
+		{"empty schema", `{}`, `"x"`, nil},
+
+		{"type ok", `{"type":"string"}`, `"x"`, nil},
+		{"type wrong", `{"type":"string"}`, `1`, []string{" type"}},
+		{"type list", `{"type":["string","null"]}`, `null`, nil},
+		{"integer ok", `{"type":"integer"}`, `3`, nil},
+		{"integer fraction", `{"type":"integer"}`, `3.5`, []string{" type"}},
+		{"number accepts integer", `{"type":"number"}`, `3`, nil},
+		{"type stops other checks", `{"type":"string","minLength":5}`, `1`, []string{" type"}},
+
+		{"enum ok", `{"enum":["a",1,null]}`, `1`, nil},
+		{"enum miss", `{"enum":["a",1]}`, `"b"`, []string{" enum"}},
+		{"const ok", `{"const":{"a":[1]}}`, `{"a":[1]}`, nil},
+		{"const miss", `{"const":{"a":[1]}}`, `{"a":[2]}`, []string{" const"}},
+
+		{"minimum boundary", `{"minimum":1}`, `1`, nil},
+		{"minimum below", `{"minimum":1}`, `0.5`, []string{" minimum"}},
+		{"maximum above", `{"maximum":1}`, `2`, []string{" maximum"}},
+		{"exclusiveMinimum boundary", `{"exclusiveMinimum":1}`, `1`, []string{" exclusiveMinimum"}},
+		{"exclusiveMaximum boundary", `{"exclusiveMaximum":1}`, `1`, []string{" exclusiveMaximum"}},
+		{"number keywords ignore strings", `{"minimum":1}`, `"0"`, nil},
+
+		{"minLength counts runes", `{"minLength":3}`, `"héé"`, nil},
+		{"minLength short", `{"minLength":3}`, `"ab"`, []string{" minLength"}},
+		{"maxLength long", `{"maxLength":2}`, `"abc"`, []string{" maxLength"}},
+		{"pattern ok", `{"pattern":"^a+$"}`, `"aaa"`, nil},
+		{"pattern miss", `{"pattern":"^a+$"}`, `"ab"`, []string{" pattern"}},
+
+		{"required missing", `{"required":["a","b"]}`, `{"a":1}`, []string{" required"}},
+		{"properties nested", `{"properties":{"a":{"type":"string"}}}`, `{"a":1}`, []string{"/a type"}},
+		{"additionalProperties false", `{"properties":{"a":{}},"additionalProperties":false}`, `{"a":1,"b":2}`, []string{"/b additionalProperties"}},
+		{"additionalProperties schema", `{"additionalProperties":{"type":"number"}}`, `{"a":1,"b":"x"}`, []string{"/b type"}},
+
+		{"minItems", `{"minItems":2}`, `[1]`, []string{" minItems"}},
+		{"maxItems", `{"maxItems":1}`, `[1,2]`, []string{" maxItems"}},
+		{"uniqueItems", `{"uniqueItems":true}`, `[1,{"a":1},{"a":1}]`, []string{" uniqueItems"}},
+		{"items", `{"items":{"type":"integer"}}`, `[1,"x",3,2.5]`, []string{"/1 type", "/3 type"}},
+		{"array keywords ignore objects", `{"minItems":2}`, `{}`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := violations(t, tt.schema, tt.instance); !slices.Equal(got, tt.want) {
+				t.Errorf("violations = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchemaViolationPointers(t *testing.T) {
+	schema := `{
+		"properties": {
+			"friends": {"items": {"properties": {"age": {"minimum": 0}}}},
+			"a/b": {"type": "string"},
+			"m~n": {"type": "string"}
+		}
+	}`
+	got := violations(t, schema, `{"friends":[{"age":1},{"age":-1}],"a/b":1,"m~n":2}`)
+	want := []string{"/a~1b type", "/friends/1/age minimum", "/m~0n type"}
+	if !slices.Equal(got, want) {
+		t.Errorf("violations = %q, want %q", got, want)
+	}
+
+	// every violation is reported, not just the first
+	if got := violations(t, `{"required":["a"],"maxProperties":1,"properties":{"b":{"type":"string"}}}`, `{"b":1}`); len(got) != 2 {
+		t.Errorf("violations = %q, want 2", got)
+	}
+}
+
+func TestInvalidSchemas(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+	}{
+		{"null", `null`},
+		{"number", `1`},
+		{"string", `"object"`},
+		{"array", `[]`},
+		{"empty", ``},
+		{"malformed", `{"type":`},
+		{"unknown type", `{"type":"text"}`},
+		{"type not a string", `{"type":1}`},
+		{"bad pattern", `{"pattern":"[a-"}`},
+		{"null property", `{"properties":{"a":null}}`},
+		{"null items", `{"items":null}`},
+		{"null additionalProperties", `{"additionalProperties":null}`},
+		{"nested error", `{"properties":{"a":{"items":{"type":"text"}}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := CompileSchema([]byte(tt.schema)); !errors.Is(err, ErrInvalidSchema) {
+				t.Errorf("CompileSchema(%s) = %v, want ErrInvalidSchema", tt.schema, err)
+			}
+		})
+	}
+}
+
+func TestSchemaDecode(t *testing.T) {
+	var p Person
+	if err := personSchema.Decode([]byte(`{"name":"Alice","age":30}`), &p); err != nil || p.Name != "Alice" {
+		t.Errorf("Decode = %+v, %v", p, err)
+	}
+	p = Person{}
+	if err := personSchema.Decode([]byte(`{"name":"Alice","age":-1}`), &p); err == nil || p.Name != "" {
+		t.Errorf("Decode of an invalid person = %+v, %v; want an error and nothing decoded", p, err)
+	}
+}